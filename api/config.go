@@ -0,0 +1,186 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/ory/viper"
+
+	"github.com/ory/oathkeeper/x"
+)
+
+const (
+	ConfigPath = "/admin/config"
+
+	maskedValue = "*****"
+)
+
+// sensitiveKeys contains parts of configuration keys whose values are masked.
+var sensitiveKeys = []string{"secret", "password", "private_key"}
+
+// sensitiveSubtrees contains configuration keys whose values are masked entirely:
+//
+// - keys: the HMAC keys of the hmac authenticator
+// - tls: the certificates and private keys of the servers, which may be configured inline
+var sensitiveSubtrees = []string{"keys", "tls"}
+
+// sensitiveSubtreeSuffixes contains suffixes of configuration keys whose values are masked entirely, for example the
+// headers sent by the remote authorizers (headers) or the oauth2_introspection authenticator
+// (introspection_request_headers), which may contain static credentials.
+var sensitiveSubtreeSuffixes = []string{"headers"}
+
+type ConfigHandler struct {
+	r configHandlerRegistry
+}
+
+type configHandlerRegistry interface {
+	x.RegistryWriter
+}
+
+func NewConfigHandler(r configHandlerRegistry) *ConfigHandler {
+	return &ConfigHandler{r: r}
+}
+
+func (h *ConfigHandler) SetRoutes(r *x.RouterAPI) {
+	r.GET(ConfigPath, h.config)
+}
+
+// swagger:route GET /admin/config api getConfig
+//
+// Retrieve the effective configuration
+//
+// This endpoint returns the fully merged configuration (configuration file, environment variables and defaults)
+// this instance actually runs with. Secrets, such as client secrets, keys and passwords in URLs, are masked.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: config
+//       500: genericError
+func (h *ConfigHandler) config(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.r.Writer().Write(w, r, MaskSecrets(viper.AllSettings()))
+}
+
+// MaskSecrets returns a copy of the configuration in which the values of sensitive keys and passwords contained
+// in URLs are masked.
+func MaskSecrets(config map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if isSensitiveKey(key) {
+			masked[key] = maskAll(value)
+			continue
+		}
+		masked[key] = maskValue(value)
+	}
+	return masked
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, subtree := range sensitiveSubtrees {
+		if key == subtree {
+			return true
+		}
+	}
+
+	for _, suffix := range sensitiveSubtreeSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func maskValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return MaskSecrets(v)
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for k, item := range v {
+			masked[k] = maskValue(item)
+		}
+		return masked
+	case []string:
+		masked := make([]interface{}, len(v))
+		for k, item := range v {
+			masked[k] = maskValue(item)
+		}
+		return masked
+	case string:
+		if strings.HasPrefix(strings.ToLower(v), "base64://") {
+			// Inline keys, for example the private signing keys of the id_token mutator.
+			return maskedValue
+		}
+		return maskURLSecrets(v)
+	}
+	return value
+}
+
+// maskAll masks every leaf value but keeps the structure so that it is still visible which keys are configured.
+func maskAll(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			masked[key] = maskAll(item)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for k, item := range v {
+			masked[k] = maskAll(item)
+		}
+		return masked
+	case nil:
+		return nil
+	}
+	return maskedValue
+}
+
+// maskURLSecrets masks the password in the userinfo and the values of sensitive query parameters, such as `password`
+// or `sslpassword` in PostgreSQL connection strings, of URLs.
+func maskURLSecrets(value string) string {
+	if !strings.Contains(value, "://") {
+		return value
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+
+	var masked bool
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			masked = true
+		}
+	}
+
+	query := u.Query()
+	for name := range query {
+		if isSensitiveKey(name) {
+			query.Set(name, "xxxxx")
+			masked = true
+		}
+	}
+
+	if !masked {
+		return value
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,8 @@
+package api
+
+// The effective configuration with secrets masked
+// swagger:response config
+type swaggerConfigResponse struct {
+	// in: body
+	Body map[string]interface{}
+}
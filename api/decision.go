@@ -23,6 +23,7 @@ package api
 import (
 	"net/http"
 
+	"github.com/ory/oathkeeper/metrics"
 	"github.com/ory/oathkeeper/pipeline/authn"
 	"github.com/ory/oathkeeper/x"
 
@@ -40,6 +41,7 @@ type decisionHandlerRegistry interface {
 
 	RuleMatcher() rule.Matcher
 	ProxyRequestHandler() *proxy.RequestHandler
+	Maintenance() *proxy.Maintenance
 }
 
 type DecisionHandler struct {
@@ -83,6 +85,7 @@ func (h *DecisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request, next
 //       403: genericError
 //       404: genericError
 //       500: genericError
+//       503: genericError
 func (h *DecisionHandler) decisions(w http.ResponseWriter, r *http.Request) {
 	fields := map[string]interface{}{
 		"http_method":     r.Method,
@@ -96,7 +99,14 @@ func (h *DecisionHandler) decisions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rl, err := h.r.RuleMatcher().Match(r.Context(), r.Method, r.URL)
-	if err != nil {
+	if h.r.Maintenance().Rejects(rl) {
+		h.r.Logger().WithError(proxy.ErrMaintenanceMode).
+			WithFields(fields).
+			WithField("granted", false).
+			Warn("Access request denied")
+		h.r.Maintenance().Write(w)
+		return
+	} else if err != nil {
 		h.r.Logger().WithError(err).
 			WithFields(fields).
 			WithField("granted", false).
@@ -105,6 +115,7 @@ func (h *DecisionHandler) decisions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	metrics.SetMatchedRule(r.Context(), rl.ID)
 	s, err := h.r.ProxyRequestHandler().HandleRequest(r, rl)
 	if err != nil {
 		h.r.Logger().WithError(err).
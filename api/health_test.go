@@ -1,6 +1,8 @@
 package api_test
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +11,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/viper"
+
+	"github.com/ory/oathkeeper/driver"
+	"github.com/ory/oathkeeper/driver/configuration"
 	"github.com/ory/oathkeeper/internal"
 	"github.com/ory/oathkeeper/x"
 )
@@ -41,3 +47,35 @@ func TestHealth(t *testing.T) {
 	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
 	assert.Equal(t, "ok", result.Status)
 }
+
+func TestHealthReadinessChecks(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	viper.Set(configuration.ViperKeyHealthReadinessChecks, []string{driver.ReadinessCheckAccessRuleRepositories})
+	viper.Set(configuration.ViperKeyAccessRuleRepositories, []string{"inline://" + base64.StdEncoding.EncodeToString([]byte(`[]`))})
+	defer viper.Reset()
+	r := internal.NewRegistry(conf)
+
+	router := x.NewAPIRouter()
+	r.HealthHandler().SetRoutes(router.Router, true)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	var result struct {
+		Errors map[string]string `json:"errors"`
+	}
+
+	res, err := server.Client().Get(server.URL + "/health/ready")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	assert.Contains(t, result.Errors, driver.ReadinessCheckAccessRuleRepositories)
+
+	_, err = r.RuleFetcher().Reload(context.Background())
+	require.NoError(t, err)
+
+	res, err = server.Client().Get(server.URL + "/health/ready")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/proxy"
+	"github.com/ory/oathkeeper/x"
+)
+
+const MaintenancePath = "/admin/maintenance"
+
+type MaintenanceHandler struct {
+	r maintenanceHandlerRegistry
+}
+
+type maintenanceHandlerRegistry interface {
+	x.RegistryWriter
+	x.RegistryLogger
+
+	Maintenance() *proxy.Maintenance
+}
+
+func NewMaintenanceHandler(r maintenanceHandlerRegistry) *MaintenanceHandler {
+	return &MaintenanceHandler{r: r}
+}
+
+func (h *MaintenanceHandler) SetRoutes(r *x.RouterAPI) {
+	r.GET(MaintenancePath, h.get)
+	r.PUT(MaintenancePath, h.set)
+}
+
+// swagger:route GET /admin/maintenance api getMaintenanceMode
+//
+// Retrieve the maintenance mode
+//
+// This endpoint returns the maintenance mode of this instance.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: maintenanceMode
+//       500: genericError
+func (h *MaintenanceHandler) get(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	h.r.Writer().Write(w, r, h.r.Maintenance().Mode())
+}
+
+// swagger:route PUT /admin/maintenance api setMaintenanceMode
+//
+// Change the maintenance mode
+//
+// While the maintenance mode is enabled, the proxy and the access control decision API reject all requests, or only
+// requests matching access rules with one of the given tags, without running the access rule pipeline. The maintenance
+// mode is kept in memory and applies to this instance only.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: maintenanceMode
+//       400: genericError
+//       500: genericError
+func (h *MaintenanceHandler) set(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var mode proxy.MaintenanceMode
+	if err := json.NewDecoder(r.Body).Decode(&mode); err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(helper.ErrBadRequest.WithReasonf("Unable to decode the maintenance mode: %s", err)))
+		return
+	}
+
+	if err := h.r.Maintenance().SetMode(mode); err != nil {
+		h.r.Writer().WriteError(w, r, errors.WithStack(helper.ErrBadRequest.WithReason(err.Error())))
+		return
+	}
+
+	mode = h.r.Maintenance().Mode()
+	h.r.Logger().
+		WithField("enabled", mode.Enabled).
+		WithField("tags", mode.Tags).
+		Info("The maintenance mode was changed")
+	h.r.Writer().Write(w, r, mode)
+}
@@ -0,0 +1,17 @@
+package api
+
+import "github.com/ory/oathkeeper/proxy"
+
+// The maintenance mode
+// swagger:response maintenanceMode
+type swaggerMaintenanceModeResponse struct {
+	// in: body
+	Body proxy.MaintenanceMode
+}
+
+// swagger:parameters setMaintenanceMode
+type swaggerSetMaintenanceModeParameters struct {
+	// in: body
+	// required: true
+	Body proxy.MaintenanceMode
+}
@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/viper"
+
+	"github.com/ory/oathkeeper/api"
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/proxy"
+	"github.com/ory/oathkeeper/rule"
+	"github.com/ory/oathkeeper/x"
+)
+
+func TestMaintenanceHandler(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	viper.Set(configuration.ViperKeyAuthenticatorNoopIsEnabled, true)
+	viper.Set(configuration.ViperKeyAuthorizerAllowIsEnabled, true)
+	viper.Set(configuration.ViperKeyMutatorNoopIsEnabled, true)
+	reg := internal.NewRegistry(conf)
+
+	router := x.NewAPIRouter()
+	reg.MaintenanceHandler().SetRoutes(router)
+	n := negroni.New(reg.DecisionHandler())
+	n.UseHandler(router)
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	newRule := func(id, path string, tags ...string) rule.Rule {
+		return rule.Rule{
+			ID:             id,
+			Tags:           tags,
+			Match:          &rule.Match{Methods: []string{"GET"}, URL: ts.URL + path},
+			Authenticators: []rule.Handler{{Handler: "noop"}},
+			Authorizer:     rule.Handler{Handler: "allow"},
+			Mutators:       []rule.Handler{{Handler: "noop"}},
+		}
+	}
+	reg.RuleRepository().(*rule.RepositoryMemory).WithRules([]rule.Rule{
+		newRule("billing", "/billing", "billing"),
+		newRule("users", "/users"),
+	})
+
+	setMode := func(t *testing.T, mode string, code int) proxy.MaintenanceMode {
+		req, err := http.NewRequest("PUT", ts.URL+api.MaintenancePath, bytes.NewBufferString(mode))
+		require.NoError(t, err)
+		res, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, code, res.StatusCode)
+
+		var m proxy.MaintenanceMode
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&m))
+		return m
+	}
+
+	decide := func(t *testing.T, path string) (int, string) {
+		res, err := ts.Client().Get(ts.URL + "/decisions" + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	t.Run("case=disabled by default", func(t *testing.T) {
+		res, err := ts.Client().Get(ts.URL + api.MaintenancePath)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var m proxy.MaintenanceMode
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&m))
+		assert.False(t, m.Enabled)
+
+		code, _ := decide(t, "/billing")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("case=rejects all requests", func(t *testing.T) {
+		m := setMode(t, `{"enabled":true}`, http.StatusOK)
+		assert.Equal(t, http.StatusServiceUnavailable, m.StatusCode)
+
+		for _, path := range []string{"/billing", "/users", "/does-not-exist"} {
+			code, _ := decide(t, path)
+			assert.Equal(t, http.StatusServiceUnavailable, code, path)
+		}
+	})
+
+	t.Run("case=rejects requests matching tagged rules", func(t *testing.T) {
+		setMode(t, `{"enabled":true,"tags":["billing"],"status_code":502,"body":"back soon"}`, http.StatusOK)
+
+		code, body := decide(t, "/billing")
+		assert.Equal(t, http.StatusBadGateway, code)
+		assert.Equal(t, "back soon", body)
+
+		code, _ = decide(t, "/users")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("case=rejects invalid status codes", func(t *testing.T) {
+		for _, code := range []int{100, 199, 600, 1000} {
+			req, err := http.NewRequest("PUT", ts.URL+api.MaintenancePath, bytes.NewBufferString(fmt.Sprintf(`{"enabled":true,"status_code":%d}`, code)))
+			require.NoError(t, err)
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, "%d", code)
+		}
+	})
+
+	t.Run("case=disable", func(t *testing.T) {
+		setMode(t, `{"enabled":false}`, http.StatusOK)
+
+		code, _ := decide(t, "/billing")
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
@@ -34,7 +34,8 @@ import (
 )
 
 const (
-	RulesPath = "/rules"
+	RulesPath  = "/rules"
+	ReloadPath = "/admin/reload"
 )
 
 type RuleHandler struct {
@@ -53,6 +54,7 @@ func NewRuleHandler(r ruleHandlerRegistry) *RuleHandler {
 func (h *RuleHandler) SetRoutes(r *x.RouterAPI) {
 	r.GET(RulesPath, h.listRules)
 	r.GET(RulesPath+"/:id", h.getRules)
+	r.POST(ReloadPath, h.reload)
 }
 
 // swagger:route GET /rules api listRules
@@ -118,3 +120,29 @@ func (h *RuleHandler) getRules(w http.ResponseWriter, r *http.Request, ps httpro
 
 	h.r.Writer().Write(w, r, rl)
 }
+
+// swagger:route POST /admin/reload api reload
+//
+// Reload access rules
+//
+// Use this method to fetch the access rules from all configured repositories immediately instead of waiting for the
+// next change notification or poll interval. The response contains the outcome for each repository. Repositories
+// which can not be fetched keep their previously loaded access rules.
+//
+//     Produces:
+//     - application/json
+//
+//     Schemes: http, https
+//
+//     Responses:
+//       200: reloadReport
+//       500: genericError
+func (h *RuleHandler) reload(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	sources, err := h.r.RuleFetcher().Reload(r.Context())
+	if err != nil {
+		h.r.Writer().WriteError(w, r, err)
+		return
+	}
+
+	h.r.Writer().Write(w, r, &ReloadReport{Sources: sources})
+}
@@ -37,6 +37,20 @@ type swaggerRulesResponse struct {
 	Body []swaggerRule
 }
 
+// ReloadReport contains the outcome of reloading the access rules of each configured repository.
+// swagger:model reloadReport
+type ReloadReport struct {
+	// Sources contains one entry per configured access rule repository.
+	Sources []rule.SourceStatus `json:"sources"`
+}
+
+// The outcome of reloading the access rules
+// swagger:response reloadReport
+type swaggerReloadReportResponse struct {
+	// in: body
+	Body ReloadReport
+}
+
 // swagger:parameters listRules
 type swaggerListRulesParameters struct {
 	// The maximum amount of rules returned.
@@ -23,11 +23,16 @@ package api_test
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
 
+	"github.com/ory/viper"
+
+	"github.com/ory/oathkeeper/api"
 	"github.com/ory/oathkeeper/driver/configuration"
 	"github.com/ory/oathkeeper/x"
 
@@ -155,4 +160,28 @@ func TestHandler(t *testing.T) {
 		})
 
 	})
+
+	t.Run("case=reload rules", func(t *testing.T) {
+		inline := base64.StdEncoding.EncodeToString([]byte(`[{"id":"reloaded-rule","match":{"url":"http://localhost/<.*>","methods":["GET"]},"authenticators":[{"handler":"noop"}],"authorizer":{"handler":"allow"},"mutators":[{"handler":"noop"}]}]`))
+		viper.Set(configuration.ViperKeyAccessRuleRepositories, []string{"inline://" + inline, "file:///does/not/exist.json"})
+		defer viper.Set(configuration.ViperKeyAccessRuleRepositories, []string{})
+
+		res, err := http.Post(server.URL+api.ReloadPath, "application/json", nil)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var report api.ReloadReport
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+		require.Len(t, report.Sources, 2)
+		assert.Equal(t, 1, report.Sources[0].Rules)
+		assert.Empty(t, report.Sources[0].Error)
+		assert.Equal(t, 0, report.Sources[1].Rules)
+		assert.NotEmpty(t, report.Sources[1].Error)
+
+		results, err := cl.API.ListRules(sdkrule.NewListRulesParams().WithLimit(pointerx.Int64(10)))
+		require.NoError(t, err)
+		require.Len(t, results.Payload, 1)
+		assert.Equal(t, "reloaded-rule", results.Payload[0].ID)
+	})
 }
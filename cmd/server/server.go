@@ -30,32 +30,44 @@ import (
 	"github.com/ory/oathkeeper/driver"
 	"github.com/ory/oathkeeper/driver/configuration"
 	"github.com/ory/oathkeeper/metrics"
+	"github.com/ory/oathkeeper/proxy"
 	"github.com/ory/oathkeeper/x"
 )
 
 func runProxy(d driver.Driver, n *negroni.Negroni, logger *logrusx.Logger, prom *metrics.PrometheusRepository) func() {
 	return func() {
-		proxy := d.Registry().Proxy()
+		p := d.Registry().Proxy()
 
 		handler := &httputil.ReverseProxy{
-			Director:  proxy.Director,
-			Transport: proxy,
+			Director:  p.Director,
+			Transport: p,
+		}
+
+		grpcHandler := &httputil.ReverseProxy{
+			Director:      p.Director,
+			Transport:     p,
+			FlushInterval: -1,
 		}
 
 		promCollapsePaths := d.Configuration().PrometheusCollapseRequestPaths()
 
 		n.Use(metrics.NewMiddleware(prom, "oathkeeper-proxy").ExcludePaths(healthx.ReadyCheckPath, healthx.AliveCheckPath).CollapsePaths(promCollapsePaths))
 		n.Use(reqlog.NewMiddlewareFromLogger(logger, "oathkeeper-proxy").ExcludePaths(healthx.ReadyCheckPath, healthx.AliveCheckPath))
-		n.UseHandler(handler)
+		n.Use(proxy.NewRecovery(d.Registry()))
+		n.UseHandler(proxy.GRPCAware(handler, grpcHandler))
 
 		h := corsx.Initialize(n, logger, "serve.proxy")
 		certs := cert("proxy", logger)
+		if certs == nil {
+			// gRPC clients connect over cleartext HTTP/2 if the proxy is not served over TLS.
+			h = proxy.H2C(h)
+		}
 
 		addr := d.Configuration().ProxyServeAddress()
 		server := graceful.WithDefaults(&http.Server{
 			Addr:         addr,
 			Handler:      h,
-			TLSConfig:    &tls.Config{Certificates: certs},
+			TLSConfig:    &tls.Config{Certificates: certs, ClientAuth: clientAuth(d)},
 			ReadTimeout:  d.Configuration().ProxyReadTimeout(),
 			WriteTimeout: d.Configuration().ProxyWriteTimeout(),
 			IdleTimeout:  d.Configuration().ProxyIdleTimeout(),
@@ -82,11 +94,14 @@ func runAPI(d driver.Driver, n *negroni.Negroni, logger *logrusx.Logger, prom *m
 		d.Registry().RuleHandler().SetRoutes(router)
 		d.Registry().HealthHandler().SetRoutes(router.Router, true)
 		d.Registry().CredentialHandler().SetRoutes(router)
+		d.Registry().ConfigHandler().SetRoutes(router)
+		d.Registry().MaintenanceHandler().SetRoutes(router)
 
 		promCollapsePaths := d.Configuration().PrometheusCollapseRequestPaths()
 
 		n.Use(metrics.NewMiddleware(prom, "oathkeeper-api").ExcludePaths(healthx.ReadyCheckPath, healthx.AliveCheckPath).CollapsePaths(promCollapsePaths))
 		n.Use(reqlog.NewMiddlewareFromLogger(logger, "oathkeeper-api").ExcludePaths(healthx.ReadyCheckPath, healthx.AliveCheckPath))
+		n.Use(proxy.NewRecovery(d.Registry()))
 		n.Use(d.Registry().DecisionHandler()) // This needs to be the last entry, otherwise the judge API won't work
 
 		n.UseHandler(router)
@@ -97,7 +112,7 @@ func runAPI(d driver.Driver, n *negroni.Negroni, logger *logrusx.Logger, prom *m
 		server := graceful.WithDefaults(&http.Server{
 			Addr:         addr,
 			Handler:      h,
-			TLSConfig:    &tls.Config{Certificates: certs},
+			TLSConfig:    &tls.Config{Certificates: certs, ClientAuth: clientAuth(d)},
 			ReadTimeout:  d.Configuration().APIReadTimeout(),
 			WriteTimeout: d.Configuration().APIWriteTimeout(),
 			IdleTimeout:  d.Configuration().APIIdleTimeout(),
@@ -160,6 +175,15 @@ func cert(daemon string, logger *logrusx.Logger) []tls.Certificate {
 	return nil
 }
 
+// clientAuth requests, but does not verify, client certificates if the mtls authenticator is enabled. Verification is
+// left to the authenticator because the trusted certificate authorities can be configured per access rule.
+func clientAuth(d driver.Driver) tls.ClientAuthType {
+	if d.Configuration().AuthenticatorIsEnabled("mtls") {
+		return tls.RequestClientCert
+	}
+	return tls.NoClientCert
+}
+
 func clusterID(c configuration.Provider) string {
 	var id bytes.Buffer
 	if err := json.NewEncoder(&id).Encode(viper.AllSettings()); err != nil {
@@ -186,6 +210,12 @@ func RunServe(version, build, date string) func(cmd *cobra.Command, args []strin
 
 		logger := logrusx.New("ORY Oathkeeper", version)
 		d := driver.NewDefaultDriver(logger, version, build, date)
+		if _, err := d.Configuration().AccessRuleIPFilter(); err != nil {
+			logger.WithError(err).Fatal("The IP filter is invalid.")
+		}
+		if _, err := proxy.NewUpstreamTransport(d.Configuration().ProxyUpstreamTransport()); err != nil {
+			logger.WithError(err).Fatal("The upstream transport is invalid.")
+		}
 		d.Registry().Init()
 
 		adminmw := negroni.New()
@@ -2,6 +2,8 @@ package configuration
 
 import (
 	"encoding/json"
+	"math/rand"
+	"net"
 	"net/url"
 	"time"
 
@@ -29,6 +31,62 @@ const (
 	Glob   MatchingStrategy = "glob"
 )
 
+// IPFilterConfig configures which client IP addresses are allowed to send requests matching any access rule.
+type IPFilterConfig struct {
+	Allow          []*net.IPNet
+	Deny           []*net.IPNet
+	TrustedProxies []*net.IPNet
+}
+
+// UpstreamTransportConfig configures the transport used to forward requests to upstreams.
+type UpstreamTransportConfig struct {
+	MaxIdleConns           int
+	MaxIdleConnsPerHost    int
+	IdleConnTimeout        time.Duration
+	TLSHandshakeTimeout    time.Duration
+	HTTP2                  bool
+	CertificateAuthorities []string
+}
+
+// UpstreamRetryConfig configures how requests to upstreams are retried.
+type UpstreamRetryConfig struct {
+	MaxAttempts    int
+	PerTryTimeout  time.Duration
+	StatusCodes    []int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+}
+
+// RetryOn returns true if a request which was answered with statusCode should be retried.
+func (c *UpstreamRetryConfig) RetryOn(statusCode int) bool {
+	for _, code := range c.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns how long to wait after the given attempt failed. The backoff starts at InitialBackoff, doubles with
+// every attempt up to MaxBackoff and is reduced by a random amount of up to half of its value, so that requests which
+// failed at the same time are not retried at the same time.
+func (c *UpstreamRetryConfig) Backoff(attempt int) time.Duration {
+	if c.InitialBackoff <= 0 || attempt < 1 {
+		return 0
+	}
+
+	d := c.InitialBackoff
+	for i := 1; i < attempt && (c.MaxBackoff <= 0 || d < c.MaxBackoff); i++ {
+		d *= 2
+	}
+	if c.MaxBackoff > 0 && d > c.MaxBackoff {
+		d = c.MaxBackoff
+	}
+
+	return d - time.Duration(rand.Int63n(int64(d)/2+1)) // #nosec G404
+}
+
 type Provider interface {
 	CORSEnabled(iface string) bool
 	CORSOptions(iface string) cors.Options
@@ -37,10 +95,13 @@ type Provider interface {
 	ProviderErrorHandlers
 	ProviderAuthorizers
 	ProviderMutators
+	ProviderResponseMutators
 
 	ProxyReadTimeout() time.Duration
 	ProxyWriteTimeout() time.Duration
 	ProxyIdleTimeout() time.Duration
+	ProxyUpstreamTransport() *UpstreamTransportConfig
+	ProxyUpstreamRetry() *UpstreamRetryConfig
 
 	APIReadTimeout() time.Duration
 	APIWriteTimeout() time.Duration
@@ -48,6 +109,11 @@ type Provider interface {
 
 	AccessRuleRepositories() []url.URL
 	AccessRuleMatchingStrategy() MatchingStrategy
+	AccessRulePipelineTimeout() time.Duration
+	AuthenticatorTimeout() time.Duration
+	AuthorizerTimeout() time.Duration
+	MutatorTimeout() time.Duration
+	AccessRuleIPFilter() (*IPFilterConfig, error)
 
 	ProxyServeAddress() string
 	APIServeAddress() string
@@ -56,6 +122,8 @@ type Provider interface {
 	PrometheusMetricsPath() string
 	PrometheusCollapseRequestPaths() bool
 
+	HealthReadinessChecks() []string
+
 	ToScopeStrategy(value string, key string) fosite.ScopeStrategy
 	ParseURLs(sources []string) ([]url.URL, error)
 	JSONWebKeyURLs() []string
@@ -84,3 +152,8 @@ type ProviderMutators interface {
 	MutatorConfig(id string, overrides json.RawMessage, destination interface{}) error
 	MutatorIsEnabled(id string) bool
 }
+
+type ProviderResponseMutators interface {
+	ResponseMutatorConfig(id string, overrides json.RawMessage, destination interface{}) error
+	ResponseMutatorIsEnabled(id string) bool
+}
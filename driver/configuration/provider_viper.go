@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/crc64"
+	"net"
+	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -41,6 +44,18 @@ const (
 	ViperKeyProxyReadTimeout                    = "serve.proxy.timeout.read"
 	ViperKeyProxyWriteTimeout                   = "serve.proxy.timeout.write"
 	ViperKeyProxyIdleTimeout                    = "serve.proxy.timeout.idle"
+	ViperKeyProxyUpstreamMaxIdleConns           = "serve.proxy.upstream.max_idle_conns"
+	ViperKeyProxyUpstreamMaxIdleConnsPerHost    = "serve.proxy.upstream.max_idle_conns_per_host"
+	ViperKeyProxyUpstreamIdleConnTimeout        = "serve.proxy.upstream.idle_conn_timeout"
+	ViperKeyProxyUpstreamTLSHandshakeTimeout    = "serve.proxy.upstream.tls_handshake_timeout"
+	ViperKeyProxyUpstreamHTTP2                  = "serve.proxy.upstream.http2"
+	ViperKeyProxyUpstreamCAs                    = "serve.proxy.upstream.certificate_authorities"
+	ViperKeyProxyUpstreamRetryMaxAttempts       = "serve.proxy.upstream.retry.max_attempts"
+	ViperKeyProxyUpstreamRetryPerTryTimeout     = "serve.proxy.upstream.retry.per_try_timeout"
+	ViperKeyProxyUpstreamRetryStatusCodes       = "serve.proxy.upstream.retry.status_codes"
+	ViperKeyProxyUpstreamRetryInitialBackoff    = "serve.proxy.upstream.retry.initial_backoff"
+	ViperKeyProxyUpstreamRetryMaxBackoff        = "serve.proxy.upstream.retry.max_backoff"
+	ViperKeyProxyUpstreamRetryTimeout           = "serve.proxy.upstream.retry.timeout"
 	ViperKeyProxyServeAddressHost               = "serve.proxy.host"
 	ViperKeyProxyServeAddressPort               = "serve.proxy.port"
 	ViperKeyAPIServeAddressHost                 = "serve.api.host"
@@ -54,6 +69,14 @@ const (
 	ViperKeyPrometheusServeCollapseRequestPaths = "serve.prometheus.collapse_request_paths"
 	ViperKeyAccessRuleRepositories              = "access_rules.repositories"
 	ViperKeyAccessRuleMatchingStrategy          = "access_rules.matching_strategy"
+	ViperKeyAccessRuleTimeoutPipeline           = "access_rules.timeouts.pipeline"
+	ViperKeyAccessRuleTimeoutAuthenticator      = "access_rules.timeouts.authenticator"
+	ViperKeyAccessRuleTimeoutAuthorizer         = "access_rules.timeouts.authorizer"
+	ViperKeyAccessRuleTimeoutMutator            = "access_rules.timeouts.mutator"
+	ViperKeyAccessRuleIPFilterAllow             = "access_rules.ip_filter.allow"
+	ViperKeyAccessRuleIPFilterDeny              = "access_rules.ip_filter.deny"
+	ViperKeyAccessRuleIPFilterTrustedProxies    = "access_rules.ip_filter.trusted_proxies"
+	ViperKeyHealthReadinessChecks               = "health.readiness.checks"
 )
 
 // Authorizers
@@ -67,6 +90,12 @@ const (
 	ViperKeyAuthorizerRemoteIsEnabled = "authorizers.remote.enabled"
 
 	ViperKeyAuthorizerRemoteJSONIsEnabled = "authorizers.remote_json.enabled"
+
+	ViperKeyAuthorizerRateLimitIsEnabled = "authorizers.rate_limit.enabled"
+
+	ViperKeyAuthorizerAllOfIsEnabled = "authorizers.all_of.enabled"
+
+	ViperKeyAuthorizerAnyOfIsEnabled = "authorizers.any_of.enabled"
 )
 
 // Mutators
@@ -81,6 +110,17 @@ const (
 
 	ViperKeyMutatorIDTokenIsEnabled = "mutators.id_token.enabled"
 	ViperKeyMutatorIDTokenJWKSURL   = "mutators.id_token.config.jwks_url"
+
+	ViperKeyMutatorCorrelationIDIsEnabled = "mutators.correlation_id.enabled"
+
+	ViperKeyMutatorTokenExchangeIsEnabled = "mutators.token_exchange.enabled"
+
+	ViperKeyMutatorSessionCookieIsEnabled = "mutators.session_cookie.enabled"
+)
+
+// Response Mutators
+const (
+	ViperKeyResponseMutatorHeaderIsEnabled = "response_mutators.header.enabled"
 )
 
 // Authenticators
@@ -105,6 +145,18 @@ const (
 
 	// unauthorized
 	ViperKeyAuthenticatorUnauthorizedIsEnabled = "authenticators.unauthorized.enabled"
+
+	// mtls
+	ViperKeyAuthenticatorMTLSIsEnabled = "authenticators.mtls.enabled"
+
+	// hmac
+	ViperKeyAuthenticatorHMACIsEnabled = "authenticators.hmac.enabled"
+
+	// aws_sigv4
+	ViperKeyAuthenticatorAWSSigV4IsEnabled = "authenticators.aws_sigv4.enabled"
+
+	// htpasswd
+	ViperKeyAuthenticatorHtpasswdIsEnabled = "authenticators.htpasswd.enabled"
 )
 
 // Errors
@@ -124,6 +176,10 @@ type ViperProvider struct {
 
 	configMutex sync.RWMutex
 	configCache map[uint64]json.RawMessage
+
+	ipFilterMutex     sync.RWMutex
+	ipFilter          *IPFilterConfig
+	ipFilterChangedAt time.Time
 }
 
 func NewViperProvider(l *logrusx.Logger) *ViperProvider {
@@ -149,6 +205,71 @@ func (v *ViperProvider) AccessRuleMatchingStrategy() MatchingStrategy {
 	return MatchingStrategy(viperx.GetString(v.l, ViperKeyAccessRuleMatchingStrategy, ""))
 }
 
+func (v *ViperProvider) AccessRulePipelineTimeout() time.Duration {
+	return viperx.GetDuration(v.l, ViperKeyAccessRuleTimeoutPipeline, 0)
+}
+
+func (v *ViperProvider) AuthenticatorTimeout() time.Duration {
+	return viperx.GetDuration(v.l, ViperKeyAccessRuleTimeoutAuthenticator, 0)
+}
+
+func (v *ViperProvider) AuthorizerTimeout() time.Duration {
+	return viperx.GetDuration(v.l, ViperKeyAccessRuleTimeoutAuthorizer, 0)
+}
+
+func (v *ViperProvider) MutatorTimeout() time.Duration {
+	return viperx.GetDuration(v.l, ViperKeyAccessRuleTimeoutMutator, 0)
+}
+
+// AccessRuleIPFilter returns the parsed global IP filter. It is parsed again only if the configuration changed. If the
+// changed configuration is invalid, the error is logged and the previous IP filter is kept.
+func (v *ViperProvider) AccessRuleIPFilter() (*IPFilterConfig, error) {
+	changedAt := viper.ConfigChangeAt()
+
+	v.ipFilterMutex.RLock()
+	c := v.ipFilter
+	cached := c != nil && v.ipFilterChangedAt.Equal(changedAt)
+	v.ipFilterMutex.RUnlock()
+	if cached {
+		return c, nil
+	}
+
+	v.ipFilterMutex.Lock()
+	defer v.ipFilterMutex.Unlock()
+
+	parsed, err := v.parseIPFilter()
+	if err != nil {
+		if v.ipFilter == nil {
+			return nil, err
+		}
+		v.l.WithError(err).Error("Ignoring the changed IP filter because it is invalid.")
+		parsed = v.ipFilter
+	}
+
+	v.ipFilter = parsed
+	v.ipFilterChangedAt = changedAt
+	return parsed, nil
+}
+
+func (v *ViperProvider) parseIPFilter() (*IPFilterConfig, error) {
+	var c IPFilterConfig
+	for _, f := range []struct {
+		key  string
+		dest *[]*net.IPNet
+	}{
+		{key: ViperKeyAccessRuleIPFilterAllow, dest: &c.Allow},
+		{key: ViperKeyAccessRuleIPFilterDeny, dest: &c.Deny},
+		{key: ViperKeyAccessRuleIPFilterTrustedProxies, dest: &c.TrustedProxies},
+	} {
+		networks, err := x.ParseNetworks(viperx.GetStringSlice(v.l, f.key, []string{}))
+		if err != nil {
+			return nil, errors.Wrapf(err, `configuration key "%s" contains an invalid network`, f.key)
+		}
+		*f.dest = networks
+	}
+	return &c, nil
+}
+
 func (v *ViperProvider) CORSEnabled(iface string) bool {
 	return corsx.IsEnabled(v.l, "serve."+iface)
 }
@@ -169,6 +290,37 @@ func (v *ViperProvider) ProxyIdleTimeout() time.Duration {
 	return viperx.GetDuration(v.l, ViperKeyProxyIdleTimeout, time.Second*120, "PROXY_SERVER_IDLE_TIMEOUT")
 }
 
+func (v *ViperProvider) ProxyUpstreamTransport() *UpstreamTransportConfig {
+	return &UpstreamTransportConfig{
+		MaxIdleConns:           viperx.GetInt(v.l, ViperKeyProxyUpstreamMaxIdleConns, 100),
+		MaxIdleConnsPerHost:    viperx.GetInt(v.l, ViperKeyProxyUpstreamMaxIdleConnsPerHost, http.DefaultMaxIdleConnsPerHost),
+		IdleConnTimeout:        viperx.GetDuration(v.l, ViperKeyProxyUpstreamIdleConnTimeout, time.Second*90),
+		TLSHandshakeTimeout:    viperx.GetDuration(v.l, ViperKeyProxyUpstreamTLSHandshakeTimeout, time.Second*10),
+		HTTP2:                  viperx.GetBool(v.l, ViperKeyProxyUpstreamHTTP2, true),
+		CertificateAuthorities: viperx.GetStringSlice(v.l, ViperKeyProxyUpstreamCAs, []string{}),
+	}
+}
+
+func (v *ViperProvider) ProxyUpstreamRetry() *UpstreamRetryConfig {
+	c := &UpstreamRetryConfig{
+		MaxAttempts:    viperx.GetInt(v.l, ViperKeyProxyUpstreamRetryMaxAttempts, 1),
+		PerTryTimeout:  viperx.GetDuration(v.l, ViperKeyProxyUpstreamRetryPerTryTimeout, 0),
+		InitialBackoff: viperx.GetDuration(v.l, ViperKeyProxyUpstreamRetryInitialBackoff, 50*time.Millisecond),
+		MaxBackoff:     viperx.GetDuration(v.l, ViperKeyProxyUpstreamRetryMaxBackoff, time.Second),
+		Timeout:        viperx.GetDuration(v.l, ViperKeyProxyUpstreamRetryTimeout, 0),
+	}
+
+	for _, code := range viperx.GetStringSlice(v.l, ViperKeyProxyUpstreamRetryStatusCodes, []string{"502", "503", "504"}) {
+		if i, err := strconv.Atoi(code); err == nil {
+			c.StatusCodes = append(c.StatusCodes, i)
+		} else {
+			v.l.WithError(err).Warnf("Ignoring invalid value of %s.", ViperKeyProxyUpstreamRetryStatusCodes)
+		}
+	}
+
+	return c
+}
+
 func (v *ViperProvider) ProxyServeAddress() string {
 	return fmt.Sprintf(
 		"%s:%d",
@@ -213,6 +365,10 @@ func (v *ViperProvider) PrometheusCollapseRequestPaths() bool {
 	return viperx.GetBool(v.l, ViperKeyPrometheusServeCollapseRequestPaths, true)
 }
 
+func (v *ViperProvider) HealthReadinessChecks() []string {
+	return viperx.GetStringSlice(v.l, ViperKeyHealthReadinessChecks, []string{})
+}
+
 func (v *ViperProvider) ParseURLs(sources []string) ([]url.URL, error) {
 	r := make([]url.URL, len(sources))
 	for k, u := range sources {
@@ -410,6 +566,14 @@ func (v *ViperProvider) MutatorConfig(id string, override json.RawMessage, dest
 	return v.PipelineConfig("mutators", id, override, dest)
 }
 
+func (v *ViperProvider) ResponseMutatorIsEnabled(id string) bool {
+	return v.pipelineIsEnabled("response_mutators", id)
+}
+
+func (v *ViperProvider) ResponseMutatorConfig(id string, override json.RawMessage, dest interface{}) error {
+	return v.PipelineConfig("response_mutators", id, override, dest)
+}
+
 func (v *ViperProvider) JSONWebKeyURLs() []string {
 	return viperx.GetStringSlice(v.l, ViperKeyMutatorIDTokenJWKSURL, []string{})
 }
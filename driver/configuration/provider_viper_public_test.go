@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/rs/cors"
 	"github.com/stretchr/testify/assert"
@@ -450,3 +451,52 @@ func TestAuthenticatorOAuth2TokenIntrospectionPreAuthorization(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessRuleIPFilter(t *testing.T) {
+	viper.Reset()
+	viper.Set(ViperKeyAccessRuleIPFilterAllow, []string{"10.0.0.0/8", "192.168.1.10"})
+	viper.Set(ViperKeyAccessRuleIPFilterTrustedProxies, []string{"172.16.0.0/12"})
+	p := NewViperProvider(logrusx.New("", ""))
+
+	c, err := p.AccessRuleIPFilter()
+	require.NoError(t, err)
+	require.Len(t, c.Allow, 2)
+	assert.Equal(t, "10.0.0.0/8", c.Allow[0].String())
+	assert.Equal(t, "192.168.1.10/32", c.Allow[1].String())
+	assert.Empty(t, c.Deny)
+	require.Len(t, c.TrustedProxies, 1)
+
+	viper.Reset()
+	viper.Set(ViperKeyAccessRuleIPFilterDeny, []string{"10.0.0.0/33"})
+
+	_, err = NewViperProvider(logrusx.New("", "")).AccessRuleIPFilter()
+	require.Error(t, err)
+
+	// The previous filter is kept if the changed configuration is invalid.
+	kept, err := p.AccessRuleIPFilter()
+	require.NoError(t, err)
+	assert.Equal(t, c, kept)
+	viper.Reset()
+}
+
+func TestUpstreamRetryConfigBackoff(t *testing.T) {
+	c := &UpstreamRetryConfig{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, expect := range map[int]time.Duration{
+		0: 0,
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		t.Run(fmt.Sprintf("attempt=%d", attempt), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				d := c.Backoff(attempt)
+				assert.True(t, d <= expect && d >= expect/2, "%s is not between %s and %s", d, expect/2, expect)
+			}
+		})
+	}
+
+	assert.Equal(t, time.Duration(0), (&UpstreamRetryConfig{MaxBackoff: time.Second}).Backoff(3))
+}
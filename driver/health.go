@@ -0,0 +1,84 @@
+package driver
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/healthx"
+	"github.com/ory/x/stringslice"
+
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+// Checks which can be configured to gate readiness.
+const (
+	ReadinessCheckAccessRuleRepositories = "access_rule_repositories"
+	ReadinessCheckJWKS                   = "jwks"
+)
+
+func (r *RegistryMemory) readyCheckers() healthx.ReadyCheckers {
+	checkers := healthx.ReadyCheckers{}
+	for _, check := range r.c.HealthReadinessChecks() {
+		switch check {
+		case ReadinessCheckAccessRuleRepositories:
+			checkers[check] = r.checkAccessRuleRepositories
+		case ReadinessCheckJWKS:
+			checkers[check] = r.checkJWKS
+		default:
+			r.Logger().WithField("check", check).Warn("Ignoring unknown readiness check.")
+		}
+	}
+	return checkers
+}
+
+// checkAccessRuleRepositories fails if the access rules of a configured repository could not be fetched.
+func (r *RegistryMemory) checkAccessRuleRepositories() error {
+	var failed []string
+	for _, status := range r.RuleFetcher().Status() {
+		if status.Error != "" {
+			failed = append(failed, status.Source+": "+status.Error)
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("access rule repositories are unavailable: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// checkJWKS fails if one of the JSON Web Key Sets configured for the jwt authenticator or the id_token mutator can
+// not be fetched.
+func (r *RegistryMemory) checkJWKS() error {
+	locations := r.c.JSONWebKeyURLs()
+	if r.c.AuthenticatorIsEnabled("jwt") {
+		var c authn.AuthenticatorOAuth2JWTConfiguration
+		if err := r.c.AuthenticatorConfig("jwt", nil, &c); err == nil {
+			locations = append(locations, c.JWKSURLs...)
+		}
+	}
+
+	var failed []string
+	for _, location := range stringslice.Unique(locations) {
+		u, err := url.Parse(location)
+		if err != nil {
+			failed = append(failed, location)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = r.CredentialsFetcher().ResolveSets(ctx, []url.URL{*u})
+		cancel()
+		if err != nil {
+			failed = append(failed, location)
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("JSON Web Key Sets can not be fetched: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
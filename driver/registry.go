@@ -15,6 +15,7 @@ import (
 	"github.com/ory/oathkeeper/pipeline/authn"
 	"github.com/ory/oathkeeper/pipeline/authz"
 	"github.com/ory/oathkeeper/pipeline/mutate"
+	"github.com/ory/oathkeeper/pipeline/response"
 	"github.com/ory/oathkeeper/rule"
 	"github.com/ory/oathkeeper/x"
 )
@@ -34,13 +35,17 @@ type Registry interface {
 	RuleHandler() *api.RuleHandler
 	DecisionHandler() *api.DecisionHandler
 	CredentialHandler() *api.CredentialsHandler
+	ConfigHandler() *api.ConfigHandler
+	MaintenanceHandler() *api.MaintenanceHandler
 
 	Proxy() *proxy.Proxy
+	Maintenance() *proxy.Maintenance
 	Tracer() *tracing.Tracer
 
 	authn.Registry
 	authz.Registry
 	mutate.Registry
+	response.Registry
 	errors.Registry
 
 	rule.Registry
@@ -25,6 +25,7 @@ import (
 	"github.com/ory/oathkeeper/pipeline/authz"
 	ep "github.com/ory/oathkeeper/pipeline/errors"
 	"github.com/ory/oathkeeper/pipeline/mutate"
+	"github.com/ory/oathkeeper/pipeline/response"
 	"github.com/ory/oathkeeper/rule"
 )
 
@@ -49,17 +50,21 @@ type RegistryMemory struct {
 	ruleValidator       rule.Validator
 	ruleRepository      *rule.RepositoryMemory
 	apiRuleHandler      *api.RuleHandler
+	apiConfigHandler    *api.ConfigHandler
+	apiMaintenance      *api.MaintenanceHandler
 	apiJudgeHandler     *api.DecisionHandler
 	healthxHandler      *healthx.Handler
 
 	proxyRequestHandler *proxy.RequestHandler
 	proxyProxy          *proxy.Proxy
+	proxyMaintenance    *proxy.Maintenance
 	ruleFetcher         rule.Fetcher
 
-	authenticators map[string]authn.Authenticator
-	authorizers    map[string]authz.Authorizer
-	mutators       map[string]mutate.Mutator
-	errors         map[string]ep.Handler
+	authenticators   map[string]authn.Authenticator
+	authorizers      map[string]authz.Authorizer
+	mutators         map[string]mutate.Mutator
+	responseMutators map[string]response.Mutator
+	errors           map[string]ep.Handler
 
 	ruleRepositoryLock sync.Mutex
 }
@@ -93,7 +98,8 @@ func (r *RegistryMemory) RuleMatcher() rule.Matcher {
 }
 
 func NewRegistryMemory() *RegistryMemory {
-	return &RegistryMemory{}
+	// The maintenance mode is initialized eagerly because it holds state which is accessed concurrently.
+	return &RegistryMemory{proxyMaintenance: proxy.NewMaintenance()}
 }
 
 func (r *RegistryMemory) BuildVersion() string {
@@ -135,7 +141,7 @@ func (r *RegistryMemory) CredentialHandler() *api.CredentialsHandler {
 
 func (r *RegistryMemory) HealthHandler() *healthx.Handler {
 	if r.healthxHandler == nil {
-		r.healthxHandler = healthx.NewHandler(r.Writer(), r.BuildVersion(), healthx.ReadyCheckers{})
+		r.healthxHandler = healthx.NewHandler(r.Writer(), r.BuildVersion(), r.readyCheckers())
 	}
 	return r.healthxHandler
 }
@@ -175,6 +181,24 @@ func (r *RegistryMemory) RuleHandler() *api.RuleHandler {
 	return r.apiRuleHandler
 }
 
+func (r *RegistryMemory) ConfigHandler() *api.ConfigHandler {
+	if r.apiConfigHandler == nil {
+		r.apiConfigHandler = api.NewConfigHandler(r)
+	}
+	return r.apiConfigHandler
+}
+
+func (r *RegistryMemory) MaintenanceHandler() *api.MaintenanceHandler {
+	if r.apiMaintenance == nil {
+		r.apiMaintenance = api.NewMaintenanceHandler(r)
+	}
+	return r.apiMaintenance
+}
+
+func (r *RegistryMemory) Maintenance() *proxy.Maintenance {
+	return r.proxyMaintenance
+}
+
 func (r *RegistryMemory) DecisionHandler() *api.DecisionHandler {
 	if r.apiJudgeHandler == nil {
 		r.apiJudgeHandler = api.NewJudgeHandler(r)
@@ -312,7 +336,7 @@ func (r *RegistryMemory) AvailablePipelineMutators() (available []string) {
 
 func (r *RegistryMemory) Proxy() *proxy.Proxy {
 	if r.proxyProxy == nil {
-		r.proxyProxy = proxy.NewProxy(r)
+		r.proxyProxy = proxy.NewProxy(r, r.c)
 	}
 
 	return r.proxyProxy
@@ -330,6 +354,31 @@ func (r *RegistryMemory) PipelineMutator(id string) (mutate.Mutator, error) {
 	return a, nil
 }
 
+func (r *RegistryMemory) AvailablePipelineResponseMutators() (available []string) {
+	r.prepareResponseMutators()
+	r.RLock()
+	defer r.RUnlock()
+
+	available = make([]string, 0, len(r.responseMutators))
+	for k := range r.responseMutators {
+		available = append(available, k)
+	}
+
+	return
+}
+
+func (r *RegistryMemory) PipelineResponseMutator(id string) (response.Mutator, error) {
+	r.prepareResponseMutators()
+	r.RLock()
+	defer r.RUnlock()
+
+	a, ok := r.responseMutators[id]
+	if !ok {
+		return nil, errors.WithStack(pipeline.ErrPipelineHandlerNotFound)
+	}
+	return a, nil
+}
+
 func (r *RegistryMemory) WithBrokenPipelineMutator() *RegistryMemory {
 	r.prepareMutators()
 	r.mutators["broken"] = mutate.NewMutatorBroken(true)
@@ -348,6 +397,10 @@ func (r *RegistryMemory) prepareAuthn() {
 			authn.NewAuthenticatorOAuth2ClientCredentials(r.c),
 			authn.NewAuthenticatorOAuth2Introspection(r.c),
 			authn.NewAuthenticatorUnauthorized(r.c),
+			authn.NewAuthenticatorMTLS(r.c),
+			authn.NewAuthenticatorHMAC(r.c),
+			authn.NewAuthenticatorAWSSigV4(r.c),
+			authn.NewAuthenticatorHtpasswd(r.c),
 		}
 
 		r.authenticators = map[string]authn.Authenticator{}
@@ -367,6 +420,9 @@ func (r *RegistryMemory) prepareAuthz() {
 			authz.NewAuthorizerKetoEngineACPORY(r.c),
 			authz.NewAuthorizerRemote(r.c),
 			authz.NewAuthorizerRemoteJSON(r.c),
+			authz.NewAuthorizerRateLimit(r.c),
+			authz.NewAuthorizerAllOf(r.c, r),
+			authz.NewAuthorizerAnyOf(r.c, r),
 		}
 
 		r.authorizers = map[string]authz.Authorizer{}
@@ -386,6 +442,9 @@ func (r *RegistryMemory) prepareMutators() {
 			mutate.NewMutatorIDToken(r.c, r),
 			mutate.NewMutatorNoop(r.c),
 			mutate.NewMutatorHydrator(r.c, r),
+			mutate.NewMutatorCorrelationID(r.c),
+			mutate.NewMutatorTokenExchange(r.c, r),
+			mutate.NewMutatorSessionCookie(r.c),
 		}
 
 		r.mutators = map[string]mutate.Mutator{}
@@ -395,6 +454,21 @@ func (r *RegistryMemory) prepareMutators() {
 	}
 }
 
+func (r *RegistryMemory) prepareResponseMutators() {
+	r.Lock()
+	defer r.Unlock()
+	if r.responseMutators == nil {
+		interim := []response.Mutator{
+			response.NewMutatorHeader(r.c),
+		}
+
+		r.responseMutators = map[string]response.Mutator{}
+		for _, a := range interim {
+			r.responseMutators[a.GetID()] = a
+		}
+	}
+}
+
 func (r *RegistryMemory) Tracer() *tracing.Tracer {
 	if r.trc == nil {
 		r.trc = &tracing.Tracer{
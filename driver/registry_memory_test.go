@@ -9,7 +9,7 @@ import (
 func TestRegistryMemoryAvailablePipelineAuthorizers(t *testing.T) {
 	r := NewRegistryMemory()
 	got := r.AvailablePipelineAuthorizers()
-	assert.ElementsMatch(t, got, []string{"allow", "deny", "keto_engine_acp_ory", "remote", "remote_json"})
+	assert.ElementsMatch(t, got, []string{"allow", "deny", "keto_engine_acp_ory", "remote", "remote_json", "rate_limit", "all_of", "any_of"})
 }
 
 func TestRegistryMemoryPipelineAuthorizer(t *testing.T) {
@@ -22,6 +22,9 @@ func TestRegistryMemoryPipelineAuthorizer(t *testing.T) {
 		{id: "keto_engine_acp_ory"},
 		{id: "remote"},
 		{id: "remote_json"},
+		{id: "rate_limit"},
+		{id: "all_of"},
+		{id: "any_of"},
 		{id: "unregistered", wantErr: true},
 	}
 	for _, tt := range tests {
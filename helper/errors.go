@@ -68,6 +68,11 @@ var (
 		CodeField:   http.StatusConflict,
 		StatusField: http.StatusText(http.StatusConflict),
 	}
+	ErrRequestEntityTooLarge = &herodot.DefaultError{
+		ErrorField:  "The request body is too large",
+		CodeField:   http.StatusRequestEntityTooLarge,
+		StatusField: http.StatusText(http.StatusRequestEntityTooLarge),
+	}
 	ErrBadRequest = &herodot.DefaultError{
 		ErrorField:  "The request is malformed or contains invalid data",
 		CodeField:   http.StatusBadRequest,
@@ -83,6 +88,11 @@ var (
 		CodeField:   http.StatusGatewayTimeout,
 		StatusField: http.StatusText(http.StatusGatewayTimeout),
 	}
+	ErrPipelineTimeout = &herodot.DefaultError{
+		ErrorField:  "The access rule pipeline did not complete in time",
+		CodeField:   http.StatusGatewayTimeout,
+		StatusField: http.StatusText(http.StatusGatewayTimeout),
+	}
 	ErrUpstreamServiceInternalServerError = &herodot.DefaultError{
 		ErrorField:  "The upstream service encountered an unexpected error",
 		CodeField:   http.StatusInternalServerError,
@@ -84,8 +84,10 @@ func (m *Middleware) getFirstPathSegment(requestURI string) string {
 }
 
 func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx, rule := withMatchedRule(r.Context())
+
 	start := m.clock.Now()
-	next(rw, r)
+	next(rw, r.WithContext(ctx))
 	latency := m.clock.Since(start)
 	res := rw.(negroni.ResponseWriter)
 
@@ -96,5 +98,9 @@ func (m *Middleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next htt
 		}
 		m.Prometheus.RequestDurationObserve(m.Name, requestURI, r.Method, res.Status())(float64(latency.Seconds()))
 		m.Prometheus.UpdateRequest(m.Name, requestURI, r.Method, res.Status())
+
+		if id := rule.ID(); id != "" {
+			m.Prometheus.RuleRequestObserve(m.Name, id, r.Method, res.Status(), latency.Seconds())
+		}
 	}
 }
@@ -120,3 +120,38 @@ func TestMiddlewareGetFirstPathSegment(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusRuleRequestTotalMetrics(t *testing.T) {
+	RuleRequestTotal.Reset()
+
+	promRepo := NewTestPrometheusRepository(RuleRequestTotal)
+	promMiddleware := NewMiddleware(promRepo, "test")
+
+	n := negroni.New(promMiddleware)
+	n.UseHandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/matched" {
+			SetMatchedRule(req.Context(), "test-rule")
+		}
+		fmt.Fprint(res, "OK")
+	})
+
+	ts := httptest.NewServer(n)
+	defer ts.Close()
+
+	for _, path := range []string{"/matched", "/matched", "/unmatched"} {
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	expected := `
+	# HELP ory_oathkeeper_rule_requests_total Total number of requests per access rule
+	# TYPE ory_oathkeeper_rule_requests_total counter
+	ory_oathkeeper_rule_requests_total{method="GET",rule_id="test-rule",service="test",status_code="200"} 2
+	`
+	if err := testutil.CollectAndCompare(RuleRequestTotal, strings.NewReader(expected), "ory_oathkeeper_rule_requests_total"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -28,6 +28,41 @@ var (
 		},
 		[]string{"service", "method", "request", "status_code"},
 	)
+	// RuleRequestTotal provides the total number of requests per matched access rule
+	RuleRequestTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ory_oathkeeper_rule_requests_total",
+			Help: "Total number of requests per access rule",
+		},
+		[]string{"service", "rule_id", "method", "status_code"},
+	)
+	// HistogramRuleRequestDuration provides the duration of requests per matched access rule
+	HistogramRuleRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ory_oathkeeper_rule_request_duration_seconds",
+			Help:    "Time spent serving requests per access rule.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"service", "rule_id", "method", "status_code"},
+	)
+	// HistogramRuleUpstreamDuration provides the duration of upstream requests per matched access rule
+	HistogramRuleUpstreamDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ory_oathkeeper_rule_upstream_duration_seconds",
+			Help:    "Time spent waiting for the upstream per access rule.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"rule_id", "method", "status_code"},
+	)
+	// HistogramPipelineHandlerDuration provides the duration of pipeline handlers per matched access rule
+	HistogramPipelineHandlerDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ory_oathkeeper_pipeline_handler_duration_seconds",
+			Help:    "Time spent in pipeline handlers per access rule.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"rule_id", "handler_type", "handler", "result"},
+	)
 )
 
 // RequestDurationObserve tracks request durations
@@ -52,6 +87,10 @@ func NewPrometheusRepository(logger *logrusx.Logger) *PrometheusRepository {
 		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 		RequestTotal,
 		HistogramRequestDuration,
+		RuleRequestTotal,
+		HistogramRuleRequestDuration,
+		HistogramRuleUpstreamDuration,
+		HistogramPipelineHandlerDuration,
 	}
 
 	r := prometheus.NewRegistry()
@@ -92,3 +131,16 @@ func (r *PrometheusRepository) UpdateRequest(service, request, method string, st
 		"status_code": strconv.Itoa(statusCode),
 	}).Inc()
 }
+
+// RuleRequestObserve tracks request durations and totals per matched access rule
+func (r *PrometheusRepository) RuleRequestObserve(service, ruleID, method string, statusCode int, v float64) {
+	labels := prometheus.Labels{
+		"service":     service,
+		"rule_id":     ruleID,
+		"method":      method,
+		"status_code": strconv.Itoa(statusCode),
+	}
+
+	HistogramRuleRequestDuration.With(labels).Observe(v)
+	RuleRequestTotal.With(labels).Inc()
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ruleContextKey int
+
+const matchedRuleKey ruleContextKey = iota + 1
+
+// Pipeline handler types used as label values of HistogramPipelineHandlerDuration
+const (
+	HandlerTypeAuthenticator   = "authenticator"
+	HandlerTypeAuthorizer      = "authorizer"
+	HandlerTypeMutator         = "mutator"
+	HandlerTypeResponseMutator = "response_mutator"
+)
+
+// Pipeline handler results used as label values of HistogramPipelineHandlerDuration
+const (
+	HandlerResultSuccess        = "success"
+	HandlerResultNotResponsible = "not_responsible"
+	HandlerResultError          = "error"
+)
+
+type matchedRule struct {
+	sync.Mutex
+	id string
+}
+
+// withMatchedRule returns a context in which SetMatchedRule can record the ID of the matched access rule.
+func withMatchedRule(ctx context.Context) (context.Context, *matchedRule) {
+	m := new(matchedRule)
+	return context.WithValue(ctx, matchedRuleKey, m), m
+}
+
+func (m *matchedRule) ID() string {
+	m.Lock()
+	defer m.Unlock()
+	return m.id
+}
+
+// SetMatchedRule records the ID of the access rule which matched the request so that the request metrics of the
+// Middleware can be labeled with it. It does nothing if the request is not served by the Middleware.
+func SetMatchedRule(ctx context.Context, ruleID string) {
+	if m, ok := ctx.Value(matchedRuleKey).(*matchedRule); ok {
+		m.Lock()
+		m.id = ruleID
+		m.Unlock()
+	}
+}
+
+// ObserveUpstream tracks the duration of a request to the upstream of an access rule.
+func ObserveUpstream(ruleID, method string, statusCode int, d time.Duration) {
+	HistogramRuleUpstreamDuration.With(prometheus.Labels{
+		"rule_id":     ruleID,
+		"method":      method,
+		"status_code": strconv.Itoa(statusCode),
+	}).Observe(d.Seconds())
+}
+
+// ObservePipelineHandler tracks the duration and result of a pipeline handler of an access rule.
+func ObservePipelineHandler(ruleID, handlerType, handler, result string, d time.Duration) {
+	HistogramPipelineHandlerDuration.With(prometheus.Labels{
+		"rule_id":      ruleID,
+		"handler_type": handlerType,
+		"handler":      handler,
+		"result":       result,
+	}).Observe(d.Seconds())
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// expectedHistogram returns the text exposition of a histogram series with the default buckets which observed d once.
+func expectedHistogram(name, labels string, d time.Duration) string {
+	var b strings.Builder
+	for _, le := range prometheus.DefBuckets {
+		count := 0
+		if d.Seconds() <= le {
+			count = 1
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(le, 'g', -1, 64), count)
+	}
+	fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} 1\n", name, labels)
+	fmt.Fprintf(&b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(d.Seconds(), 'g', -1, 64))
+	fmt.Fprintf(&b, "%s_count{%s} 1\n", name, labels)
+	return b.String()
+}
+
+func TestObserveUpstream(t *testing.T) {
+	HistogramRuleUpstreamDuration.Reset()
+
+	ObserveUpstream("test-rule", "GET", 200, 20*time.Millisecond)
+	ObserveUpstream("test-rule", "GET", 502, 3*time.Second)
+
+	if n := testutil.CollectAndCount(HistogramRuleUpstreamDuration); n != 2 {
+		t.Fatalf("Expected 2 series but got %d", n)
+	}
+
+	const name = "ory_oathkeeper_rule_upstream_duration_seconds"
+	expected := `
+	# HELP ory_oathkeeper_rule_upstream_duration_seconds Time spent waiting for the upstream per access rule.
+	# TYPE ory_oathkeeper_rule_upstream_duration_seconds histogram
+	` + expectedHistogram(name, `method="GET",rule_id="test-rule",status_code="200"`, 20*time.Millisecond) +
+		expectedHistogram(name, `method="GET",rule_id="test-rule",status_code="502"`, 3*time.Second)
+	if err := testutil.CollectAndCompare(HistogramRuleUpstreamDuration, strings.NewReader(expected), name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestObservePipelineHandler(t *testing.T) {
+	HistogramPipelineHandlerDuration.Reset()
+
+	ObservePipelineHandler("test-rule", HandlerTypeAuthenticator, "oauth2_introspection", HandlerResultNotResponsible, time.Millisecond)
+	ObservePipelineHandler("test-rule", HandlerTypeAuthenticator, "jwt", HandlerResultSuccess, 20*time.Millisecond)
+	ObservePipelineHandler("test-rule", HandlerTypeAuthorizer, "remote_json", HandlerResultError, 300*time.Millisecond)
+
+	if n := testutil.CollectAndCount(HistogramPipelineHandlerDuration); n != 3 {
+		t.Fatalf("Expected 3 series but got %d", n)
+	}
+
+	const name = "ory_oathkeeper_pipeline_handler_duration_seconds"
+	expected := `
+	# HELP ory_oathkeeper_pipeline_handler_duration_seconds Time spent in pipeline handlers per access rule.
+	# TYPE ory_oathkeeper_pipeline_handler_duration_seconds histogram
+	` + expectedHistogram(name, `handler="jwt",handler_type="authenticator",result="success",rule_id="test-rule"`, 20*time.Millisecond) +
+		expectedHistogram(name, `handler="oauth2_introspection",handler_type="authenticator",result="not_responsible",rule_id="test-rule"`, time.Millisecond) +
+		expectedHistogram(name, `handler="remote_json",handler_type="authorizer",result="error",rule_id="test-rule"`, 300*time.Millisecond)
+	if err := testutil.CollectAndCompare(HistogramPipelineHandlerDuration, strings.NewReader(expected), name); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,306 @@
+package authn
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ory/x/stringslice"
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/pipeline"
+)
+
+const (
+	awsSigV4Algorithm  = "AWS4-HMAC-SHA256"
+	awsSigV4TimeFormat = "20060102T150405Z"
+	awsSigV4DateFormat = "20060102"
+
+	awsSigV4UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+type AuthenticatorAWSSigV4AccessKey struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Subject         string `json:"subject"`
+}
+
+type AuthenticatorAWSSigV4Configuration struct {
+	AccessKeys             []AuthenticatorAWSSigV4AccessKey `json:"access_keys"`
+	Region                 string                           `json:"region"`
+	Service                string                           `json:"service"`
+	MaxClockSkew           string                           `json:"max_clock_skew"`
+	DisableURIPathEscaping bool                             `json:"disable_uri_path_escaping"`
+	MaxBodySize            int64                            `json:"max_body_size"`
+	AllowUnsignedPayload   bool                             `json:"allow_unsigned_payload"`
+}
+
+// AuthenticatorAWSSigV4 authenticates requests which are signed using AWS Signature Version 4 with one of the
+// configured access keys.
+type AuthenticatorAWSSigV4 struct {
+	c configuration.Provider
+}
+
+func NewAuthenticatorAWSSigV4(c configuration.Provider) *AuthenticatorAWSSigV4 {
+	return &AuthenticatorAWSSigV4{c: c}
+}
+
+func (a *AuthenticatorAWSSigV4) GetID() string {
+	return "aws_sigv4"
+}
+
+func (a *AuthenticatorAWSSigV4) Validate(config json.RawMessage) error {
+	if !a.c.AuthenticatorIsEnabled(a.GetID()) {
+		return NewErrAuthenticatorNotEnabled(a)
+	}
+
+	_, err := a.Config(config)
+	return err
+}
+
+func (a *AuthenticatorAWSSigV4) Config(config json.RawMessage) (*AuthenticatorAWSSigV4Configuration, error) {
+	var c AuthenticatorAWSSigV4Configuration
+	if err := a.c.AuthenticatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	if c.MaxClockSkew == "" {
+		c.MaxClockSkew = "15m"
+	}
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = defaultMaxBodySize
+	}
+
+	return &c, nil
+}
+
+func (a *AuthenticatorAWSSigV4) Authenticate(r *http.Request, session *AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, awsSigV4Algorithm+" ") {
+		return errors.WithStack(ErrAuthenticatorNotResponsible)
+	}
+
+	cf, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	skew, err := time.ParseDuration(cf.MaxClockSkew)
+	if err != nil {
+		return NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	credential, signedHeaders, signature, err := parseAWSSigV4Authorization(auth)
+	if err != nil {
+		return helper.ErrUnauthorized.WithReason(err.Error()).WithTrace(err)
+	}
+
+	// Without these headers a signature could be replayed against another host or its date could be changed.
+	for _, required := range []string{"host", "x-amz-date"} {
+		if !stringslice.Has(signedHeaders, required) {
+			return helper.ErrUnauthorized.WithReasonf(`Header "%s" must be signed.`, required)
+		}
+	}
+
+	// The credential scope is <access key id>/<date>/<region>/<service>/aws4_request.
+	scope := strings.Split(credential, "/")
+	if len(scope) != 5 || scope[4] != "aws4_request" {
+		return helper.ErrUnauthorized.WithReasonf(`The credential scope "%s" is invalid.`, credential)
+	}
+	accessKeyID, date, region, service := scope[0], scope[1], scope[2], scope[3]
+
+	if cf.Region != "" && cf.Region != region {
+		return helper.ErrUnauthorized.WithReasonf(`The request must be signed for region "%s".`, cf.Region)
+	}
+	if cf.Service != "" && cf.Service != service {
+		return helper.ErrUnauthorized.WithReasonf(`The request must be signed for service "%s".`, cf.Service)
+	}
+
+	var key *AuthenticatorAWSSigV4AccessKey
+	for k := range cf.AccessKeys {
+		if cf.AccessKeys[k].AccessKeyID == accessKeyID {
+			key = &cf.AccessKeys[k]
+			break
+		}
+	}
+	if key == nil {
+		return helper.ErrUnauthorized.WithReasonf(`Access key "%s" is unknown.`, accessKeyID)
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse(awsSigV4TimeFormat, amzDate)
+	if err != nil {
+		return helper.ErrUnauthorized.WithReason(`Header "X-Amz-Date" is missing or malformed.`)
+	}
+
+	if now := time.Now(); signedAt.Before(now.Add(-skew)) || signedAt.After(now.Add(skew)) {
+		return helper.ErrUnauthorized.WithReason("The request signature has expired or is not yet valid.")
+	}
+
+	if signedAt.Format(awsSigV4DateFormat) != date {
+		return helper.ErrUnauthorized.WithReason(`The credential scope does not match header "X-Amz-Date".`)
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == awsSigV4UnsignedPayload {
+		if !cf.AllowUnsignedPayload {
+			return helper.ErrUnauthorized.WithReason("Requests with an unsigned payload are not allowed.")
+		}
+	} else {
+		bodyHash, err := requestBodyHash(r, cf.MaxBodySize)
+		if err != nil {
+			return err
+		}
+
+		if payloadHash != "" && !strings.EqualFold(payloadHash, bodyHash) {
+			return helper.ErrUnauthorized.WithReason(`The request body does not match header "X-Amz-Content-Sha256".`)
+		}
+		payloadHash = bodyHash
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		awsSigV4CanonicalURI(r, cf.DisableURIPathEscaping),
+		awsSigV4CanonicalQuery(r),
+		awsSigV4CanonicalHeaders(r, signedHeaders),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	hashedCanonicalRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		awsSigV4Algorithm,
+		amzDate,
+		strings.Join(scope[1:], "/"),
+		hex.EncodeToString(hashedCanonicalRequest[:]),
+	}, "\n")
+
+	signingKey := awsSigV4HMAC([]byte("AWS4"+key.SecretAccessKey), date)
+	signingKey = awsSigV4HMAC(signingKey, region)
+	signingKey = awsSigV4HMAC(signingKey, service)
+	signingKey = awsSigV4HMAC(signingKey, "aws4_request")
+
+	expected := hex.EncodeToString(awsSigV4HMAC(signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return helper.ErrUnauthorized.WithReason("The request signature is invalid.")
+	}
+
+	session.Subject = key.Subject
+	if session.Subject == "" {
+		session.Subject = key.AccessKeyID
+	}
+	session.Extra = map[string]interface{}{
+		"access_key_id": key.AccessKeyID,
+		"region":        region,
+		"service":       service,
+	}
+
+	return nil
+}
+
+func parseAWSSigV4Authorization(auth string) (credential string, signedHeaders []string, signature string, err error) {
+	for _, part := range strings.Split(strings.TrimPrefix(auth, awsSigV4Algorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "Credential":
+			credential = kv[1]
+		case "SignedHeaders":
+			signedHeaders = strings.Split(kv[1], ";")
+		case "Signature":
+			signature = kv[1]
+		}
+	}
+
+	if credential == "" || len(signedHeaders) == 0 || signature == "" {
+		return "", nil, "", errors.New("The authorization header must contain Credential, SignedHeaders and Signature.")
+	}
+
+	return credential, signedHeaders, signature, nil
+}
+
+func awsSigV4HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func awsSigV4CanonicalURI(r *http.Request, disableEscaping bool) string {
+	path := r.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	if disableEscaping {
+		return path
+	}
+
+	return awsSigV4Escape(path, false)
+}
+
+func awsSigV4CanonicalQuery(r *http.Request) string {
+	query := r.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(query))
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, awsSigV4Escape(k, true)+"="+awsSigV4Escape(v, true))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func awsSigV4CanonicalHeaders(r *http.Request, signedHeaders []string) string {
+	var b strings.Builder
+	for _, h := range signedHeaders {
+		raw := r.Header[http.CanonicalHeaderKey(h)]
+		if h == "host" {
+			raw = []string{r.Host}
+		}
+
+		values := make([]string, len(raw))
+		for k, v := range raw {
+			values[k] = strings.Join(strings.Fields(v), " ")
+		}
+
+		b.WriteString(h + ":" + strings.Join(values, ",") + "\n")
+	}
+
+	return b.String()
+}
+
+// awsSigV4Escape percent-encodes all characters except the unreserved ones. The slash is only encoded if encodeSlash
+// is true.
+func awsSigV4Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%%%02X", c))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,248 @@
+package authn_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+// signAWSSigV4Request signs a request without query parameters for region "us-east-1" and service "service".
+func signAWSSigV4Request(r *http.Request, payloadHash string, signedHeaders []string) {
+	now := time.Now().UTC()
+	r.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+
+	var headers strings.Builder
+	for _, h := range signedHeaders {
+		v := r.Header.Get(h)
+		if h == "host" {
+			v = r.Host
+		}
+		headers.WriteString(h + ":" + v + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{r.Method, r.URL.EscapedPath(), "", headers.String(), strings.Join(signedHeaders, ";"), payloadHash}, "\n")
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	scope := now.Format("20060102") + "/us-east-1/service/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", now.Format("20060102T150405Z"), scope, hex.EncodeToString(hashed[:])}, "\n")
+
+	sign := func(key []byte, data string) []byte {
+		mac := hmac.New(sha256.New, key)
+		_, _ = mac.Write([]byte(data))
+		return mac.Sum(nil)
+	}
+	key := []byte("AWS4wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	for _, part := range strings.Split(scope, "/") {
+		key = sign(key, part)
+	}
+
+	r.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/%s, SignedHeaders=%s, Signature=%s",
+		scope, strings.Join(signedHeaders, ";"), hex.EncodeToString(sign(key, stringToSign))))
+}
+
+func TestAuthenticatorAWSSigV4(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineAuthenticator("aws_sigv4")
+	require.NoError(t, err)
+	assert.Equal(t, "aws_sigv4", a.GetID())
+
+	// The "get-vanilla" case of the AWS Signature Version 4 test suite. It was signed in 2015, hence the clock skew.
+	config := json.RawMessage(`{
+		"access_keys": [{"access_key_id":"AKIDEXAMPLE","secret_access_key":"wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY","subject":"peter"}],
+		"max_clock_skew": "1000000h"
+	}`)
+	vanilla := func() *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = "example.amazonaws.com"
+		r.Header.Set("X-Amz-Date", "20150830T123600Z")
+		r.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31")
+		return r
+	}
+
+	signedBody := func(body string, withHashHeader bool, signedHeaders string) *http.Request {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		hash := sha256.Sum256([]byte(body))
+		if withHashHeader {
+			r.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hash[:]))
+		}
+		signAWSSigV4Request(r, hex.EncodeToString(hash[:]), strings.Split(signedHeaders, ";"))
+		return r
+	}
+
+	t.Run("method=authenticate", func(t *testing.T) {
+		for k, tc := range []struct {
+			d         string
+			config    json.RawMessage
+			r         func() *http.Request
+			expectErr bool
+		}{
+			{
+				d: "should not be responsible without signature",
+				r: func() *http.Request {
+					return httptest.NewRequest("GET", "/", nil)
+				},
+				expectErr: true,
+			},
+			{
+				d: "should pass with a valid signature",
+				r: vanilla,
+			},
+			{
+				d:      "should pass because the region and service match",
+				config: json.RawMessage(`{"region":"us-east-1","service":"service"}`),
+				r:      vanilla,
+			},
+			{
+				d:         "should fail because the region does not match",
+				config:    json.RawMessage(`{"region":"eu-central-1"}`),
+				r:         vanilla,
+				expectErr: true,
+			},
+			{
+				d: "should fail because the host was modified",
+				r: func() *http.Request {
+					r := vanilla()
+					r.Host = "example.com"
+					return r
+				},
+				expectErr: true,
+			},
+			{
+				d: "should fail because the path was modified",
+				r: func() *http.Request {
+					r := vanilla()
+					r.URL.Path = "/foo"
+					return r
+				},
+				expectErr: true,
+			},
+			{
+				d:         "should fail because the signature has expired",
+				config:    json.RawMessage(`{"max_clock_skew":"15m"}`),
+				r:         vanilla,
+				expectErr: true,
+			},
+			{
+				d: "should pass with a signed body",
+				r: func() *http.Request {
+					return signedBody(`{"foo":"bar"}`, true, "host;x-amz-content-sha256;x-amz-date")
+				},
+			},
+			{
+				d: "should pass with a signed body without the content hash header",
+				r: func() *http.Request {
+					return signedBody(`{"foo":"bar"}`, false, "host;x-amz-date")
+				},
+			},
+			{
+				d: "should fail because the body was modified",
+				r: func() *http.Request {
+					r := signedBody(`{"foo":"bar"}`, true, "host;x-amz-content-sha256;x-amz-date")
+					r.Body = ioutil.NopCloser(strings.NewReader(`{"foo":"baz"}`))
+					return r
+				},
+				expectErr: true,
+			},
+			{
+				d: "should fail because the body was modified and the content hash header is missing",
+				r: func() *http.Request {
+					r := signedBody(`{"foo":"bar"}`, false, "host;x-amz-date")
+					r.Body = ioutil.NopCloser(strings.NewReader(`{"foo":"baz"}`))
+					return r
+				},
+				expectErr: true,
+			},
+			{
+				d: "should fail because the payload is unsigned",
+				r: func() *http.Request {
+					r := httptest.NewRequest("POST", "/", strings.NewReader(`{"foo":"bar"}`))
+					r.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+					signAWSSigV4Request(r, "UNSIGNED-PAYLOAD", []string{"host", "x-amz-content-sha256", "x-amz-date"})
+					return r
+				},
+				expectErr: true,
+			},
+			{
+				d:      "should pass because unsigned payloads are allowed",
+				config: json.RawMessage(`{"allow_unsigned_payload":true}`),
+				r: func() *http.Request {
+					r := httptest.NewRequest("POST", "/", strings.NewReader(`{"foo":"bar"}`))
+					r.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+					signAWSSigV4Request(r, "UNSIGNED-PAYLOAD", []string{"host", "x-amz-content-sha256", "x-amz-date"})
+					return r
+				},
+			},
+			{
+				d: "should fail because the host is not signed",
+				r: func() *http.Request {
+					return signedBody(`{"foo":"bar"}`, false, "x-amz-date")
+				},
+				expectErr: true,
+			},
+			{
+				d: "should fail because the date is not signed",
+				r: func() *http.Request {
+					return signedBody(`{"foo":"bar"}`, false, "host")
+				},
+				expectErr: true,
+			},
+			{
+				d:         "should fail because the access key is unknown",
+				config:    json.RawMessage(`{"access_keys":[{"access_key_id":"foo","secret_access_key":"bar"}]}`),
+				r:         vanilla,
+				expectErr: true,
+			},
+		} {
+			t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+				var base, override map[string]interface{}
+				require.NoError(t, json.Unmarshal(config, &base))
+				if tc.config != nil {
+					require.NoError(t, json.Unmarshal(tc.config, &override))
+				}
+				for k, v := range override {
+					base[k] = v
+				}
+				raw, err := json.Marshal(base)
+				require.NoError(t, err)
+
+				session := new(authn.AuthenticationSession)
+				err = a.Authenticate(tc.r(), session, raw, nil)
+				if tc.expectErr {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, "peter", session.Subject)
+				assert.Equal(t, "AKIDEXAMPLE", session.Extra["access_key_id"])
+			})
+		}
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		viper.Set(configuration.ViperKeyAuthenticatorAWSSigV4IsEnabled, true)
+		require.NoError(t, a.Validate(config))
+		require.Error(t, a.Validate(json.RawMessage(`{"access_keys":[]}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyAuthenticatorAWSSigV4IsEnabled, false)
+		require.Error(t, a.Validate(config))
+	})
+}
@@ -0,0 +1,203 @@
+package authn
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/pipeline"
+)
+
+type AuthenticatorHMACConfiguration struct {
+	Keys            map[string]string `json:"keys"`
+	KeyIDHeader     string            `json:"key_id_header"`
+	SignatureHeader string            `json:"signature_header"`
+	TimestampHeader string            `json:"timestamp_header"`
+	MaxClockSkew    string            `json:"max_clock_skew"`
+	MaxBodySize     int64             `json:"max_body_size"`
+}
+
+// defaultMaxBodySize is the default maximum size of a request body which is buffered to verify its hash.
+const defaultMaxBodySize = 1 << 20
+
+// AuthenticatorHMAC authenticates requests which are signed with a shared secret. The signature is the hex encoded
+// HMAC-SHA256 of the request's method, path and query, the hex encoded SHA-256 of its body and a unix timestamp,
+// separated by line feeds. A signature is accepted only once and only within the configured clock skew.
+type AuthenticatorHMAC struct {
+	c configuration.Provider
+
+	sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewAuthenticatorHMAC(c configuration.Provider) *AuthenticatorHMAC {
+	return &AuthenticatorHMAC{
+		c:         c,
+		seen:      map[string]time.Time{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (a *AuthenticatorHMAC) GetID() string {
+	return "hmac"
+}
+
+func (a *AuthenticatorHMAC) Validate(config json.RawMessage) error {
+	if !a.c.AuthenticatorIsEnabled(a.GetID()) {
+		return NewErrAuthenticatorNotEnabled(a)
+	}
+
+	_, err := a.Config(config)
+	return err
+}
+
+func (a *AuthenticatorHMAC) Config(config json.RawMessage) (*AuthenticatorHMACConfiguration, error) {
+	var c AuthenticatorHMACConfiguration
+	if err := a.c.AuthenticatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	if c.KeyIDHeader == "" {
+		c.KeyIDHeader = "X-Key-Id"
+	}
+	if c.SignatureHeader == "" {
+		c.SignatureHeader = "X-Signature"
+	}
+	if c.TimestampHeader == "" {
+		c.TimestampHeader = "X-Timestamp"
+	}
+	if c.MaxClockSkew == "" {
+		c.MaxClockSkew = "5m"
+	}
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = defaultMaxBodySize
+	}
+
+	return &c, nil
+}
+
+func (a *AuthenticatorHMAC) Authenticate(r *http.Request, session *AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	cf, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	keyID := r.Header.Get(cf.KeyIDHeader)
+	signature := r.Header.Get(cf.SignatureHeader)
+	if keyID == "" || signature == "" {
+		return errors.WithStack(ErrAuthenticatorNotResponsible)
+	}
+
+	skew, err := time.ParseDuration(cf.MaxClockSkew)
+	if err != nil {
+		return NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	secret, ok := cf.Keys[keyID]
+	if !ok {
+		return helper.ErrUnauthorized.WithReasonf(`Key "%s" is unknown.`, keyID)
+	}
+
+	timestamp := r.Header.Get(cf.TimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return helper.ErrUnauthorized.WithReasonf(`Header "%s" must contain a unix timestamp.`, cf.TimestampHeader)
+	}
+
+	now := time.Now()
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-skew)) || signedAt.After(now.Add(skew)) {
+		return helper.ErrUnauthorized.WithReason("The request signature has expired or is not yet valid.")
+	}
+
+	body, err := requestBodyHash(r, cf.MaxBodySize)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%s\n%s", r.Method, r.URL.RequestURI(), body, timestamp)
+
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return helper.ErrUnauthorized.WithReason("The request signature is invalid.")
+	}
+
+	if !a.markSeen(keyID+"|"+signature, signedAt.Add(skew), now) {
+		return helper.ErrUnauthorized.WithReason("The request signature has already been used.")
+	}
+
+	session.Subject = keyID
+	return nil
+}
+
+// markSeen records a signature until it expires. It returns false if the signature has been recorded before.
+func (a *AuthenticatorHMAC) markSeen(key string, expiresAt, now time.Time) bool {
+	a.Lock()
+	defer a.Unlock()
+
+	if now.Sub(a.lastSweep) > time.Minute {
+		for k, exp := range a.seen {
+			if now.After(exp) {
+				delete(a.seen, k)
+			}
+		}
+		a.lastSweep = now
+	}
+
+	if exp, ok := a.seen[key]; ok && !now.After(exp) {
+		return false
+	}
+
+	a.seen[key] = expiresAt
+	return true
+}
+
+// requestBodyHash returns the hex encoded SHA-256 of the request body and restores the body afterwards. Bodies larger
+// than maxSize bytes are rejected without being buffered completely.
+func requestBodyHash(r *http.Request, maxSize int64) (string, error) {
+	h := sha256.New()
+	if r.Body == nil || r.Body == http.NoBody {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	if r.ContentLength > maxSize {
+		return "", errors.WithStack(helper.ErrRequestEntityTooLarge.WithReasonf("The request body must not be larger than %d bytes.", maxSize))
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSize+1))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if int64(len(body)) > maxSize {
+		// Restore what was read so that the body is complete if the request is handled by another authenticator.
+		r.Body = &bodyReadCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		return "", errors.WithStack(helper.ErrRequestEntityTooLarge.WithReasonf("The request body must not be larger than %d bytes.", maxSize))
+	}
+
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type bodyReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,153 @@
+package authn_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ory/herodot"
+	"github.com/ory/viper"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+func signHMACRequest(r *http.Request, keyID, secret string, body []byte, at time.Time) {
+	bodyHash := sha256.Sum256(body)
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%s\n%s", r.Method, r.URL.RequestURI(), hex.EncodeToString(bodyHash[:]), timestamp)
+
+	r.Header.Set("X-Key-Id", keyID)
+	r.Header.Set("X-Timestamp", timestamp)
+	r.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestAuthenticatorHMAC(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineAuthenticator("hmac")
+	require.NoError(t, err)
+	assert.Equal(t, "hmac", a.GetID())
+
+	config := json.RawMessage(`{"keys":{"partner-a":"secret-a"},"max_clock_skew":"1m"}`)
+	body := []byte(`{"foo":"bar"}`)
+
+	t.Run("method=authenticate", func(t *testing.T) {
+		for k, tc := range []struct {
+			d             string
+			setup         func(r *http.Request)
+			expectErr     bool
+			expectSubject string
+		}{
+			{
+				d:         "should not be responsible without signature",
+				setup:     func(r *http.Request) {},
+				expectErr: true,
+			},
+			{
+				d: "should pass with a valid signature",
+				setup: func(r *http.Request) {
+					signHMACRequest(r, "partner-a", "secret-a", body, time.Now())
+				},
+				expectSubject: "partner-a",
+			},
+			{
+				d: "should fail because the key is unknown",
+				setup: func(r *http.Request) {
+					signHMACRequest(r, "partner-b", "secret-a", body, time.Now())
+				},
+				expectErr: true,
+			},
+			{
+				d: "should fail because the secret is wrong",
+				setup: func(r *http.Request) {
+					signHMACRequest(r, "partner-a", "secret-b", body, time.Now())
+				},
+				expectErr: true,
+			},
+			{
+				d: "should fail because the signature has expired",
+				setup: func(r *http.Request) {
+					signHMACRequest(r, "partner-a", "secret-a", body, time.Now().Add(-time.Hour))
+				},
+				expectErr: true,
+			},
+			{
+				d: "should fail because the body was modified",
+				setup: func(r *http.Request) {
+					signHMACRequest(r, "partner-a", "secret-a", []byte(`{"foo":"baz"}`), time.Now())
+				},
+				expectErr: true,
+			},
+		} {
+			t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+				r := httptest.NewRequest("POST", "/orders?page=2", bytes.NewReader(body))
+				tc.setup(r)
+
+				session := new(authn.AuthenticationSession)
+				err := a.Authenticate(r, session, config, nil)
+				if tc.expectErr {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectSubject, session.Subject)
+
+				forwarded, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, body, forwarded)
+			})
+		}
+	})
+
+	t.Run("method=authenticate/case=should reject replayed signatures", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/replay", nil)
+		signHMACRequest(r, "partner-a", "secret-a", nil, time.Now())
+
+		require.NoError(t, a.Authenticate(r, new(authn.AuthenticationSession), config, nil))
+		require.Error(t, a.Authenticate(r, new(authn.AuthenticationSession), config, nil))
+	})
+
+	t.Run("method=authenticate/case=should reject bodies which are too large", func(t *testing.T) {
+		large := bytes.Repeat([]byte("a"), 64)
+		for _, contentLength := range []int64{int64(len(large)), -1} {
+			r := httptest.NewRequest("POST", "/large", bytes.NewReader(large))
+			r.ContentLength = contentLength
+			signHMACRequest(r, "partner-a", "secret-a", large, time.Now())
+
+			err := a.Authenticate(r, new(authn.AuthenticationSession), json.RawMessage(`{"keys":{"partner-a":"secret-a"},"max_body_size":32}`), nil)
+			require.Error(t, err)
+
+			herr, ok := errors.Cause(err).(*herodot.DefaultError)
+			require.True(t, ok, "%+v", err)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, herr.StatusCode())
+		}
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		viper.Set(configuration.ViperKeyAuthenticatorHMACIsEnabled, true)
+		require.NoError(t, a.Validate(config))
+		require.Error(t, a.Validate(json.RawMessage(`{}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyAuthenticatorHMACIsEnabled, false)
+		require.Error(t, a.Validate(config))
+	})
+}
@@ -0,0 +1,176 @@
+package authn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/pipeline"
+)
+
+type AuthenticatorHtpasswdConfiguration struct {
+	Path string `json:"path"`
+}
+
+// htpasswdDummyPassword is hashed with the cost used in an htpasswd file to obtain the hash which the passwords of
+// unknown users are compared with.
+const htpasswdDummyPassword = "oathkeeper-htpasswd-unknown-user"
+
+type htpasswdFile struct {
+	modTime time.Time
+	size    int64
+	users   map[string][]byte
+
+	// dummyHash is compared with the password of unknown users so that they take as long to reject as known users
+	// with a wrong password, which would otherwise reveal which usernames exist.
+	dummyHash []byte
+}
+
+// AuthenticatorHtpasswd authenticates requests using HTTP Basic Authorization against an htpasswd file with bcrypt
+// hashed passwords. The file is reloaded when it changes.
+type AuthenticatorHtpasswd struct {
+	c configuration.Provider
+
+	sync.RWMutex
+	files map[string]*htpasswdFile
+}
+
+func NewAuthenticatorHtpasswd(c configuration.Provider) *AuthenticatorHtpasswd {
+	return &AuthenticatorHtpasswd{
+		c:     c,
+		files: map[string]*htpasswdFile{},
+	}
+}
+
+func (a *AuthenticatorHtpasswd) GetID() string {
+	return "htpasswd"
+}
+
+func (a *AuthenticatorHtpasswd) Validate(config json.RawMessage) error {
+	if !a.c.AuthenticatorIsEnabled(a.GetID()) {
+		return NewErrAuthenticatorNotEnabled(a)
+	}
+
+	cf, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.file(cf.Path); err != nil {
+		return NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	return nil
+}
+
+func (a *AuthenticatorHtpasswd) Config(config json.RawMessage) (*AuthenticatorHtpasswdConfiguration, error) {
+	var c AuthenticatorHtpasswdConfiguration
+	if err := a.c.AuthenticatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	return &c, nil
+}
+
+func (a *AuthenticatorHtpasswd) Authenticate(r *http.Request, session *AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return errors.WithStack(ErrAuthenticatorNotResponsible)
+	}
+
+	cf, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	f, err := a.file(cf.Path)
+	if err != nil {
+		return NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	hash, ok := f.users[username]
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(f.dummyHash, []byte(password))
+		return errors.WithStack(helper.ErrUnauthorized.WithReason("The username or password is invalid."))
+	}
+
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		return errors.WithStack(helper.ErrUnauthorized.WithReason("The username or password is invalid."))
+	}
+
+	session.Subject = username
+	return nil
+}
+
+// file returns the htpasswd file at path. The file is parsed again if its size or modification time has changed since
+// it was last read.
+func (a *AuthenticatorHtpasswd) file(path string) (*htpasswdFile, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	a.RLock()
+	f, ok := a.files[path]
+	a.RUnlock()
+	if ok && f.modTime.Equal(fi.ModTime()) && f.size == fi.Size() {
+		return f, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	users := map[string][]byte{}
+	cost := bcrypt.MinCost
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf(`line %d of htpasswd file "%s" is malformed`, line, path)
+		}
+
+		c, err := bcrypt.Cost([]byte(parts[1]))
+		if err != nil {
+			return nil, errors.Errorf(`line %d of htpasswd file "%s" does not contain a bcrypt hash`, line, path)
+		}
+		if c > cost {
+			cost = c
+		}
+
+		users[parts[0]] = []byte(parts[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte(htpasswdDummyPassword), cost)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	f = &htpasswdFile{modTime: fi.ModTime(), size: fi.Size(), users: users, dummyHash: dummyHash}
+	a.Lock()
+	a.files[path] = f
+	a.Unlock()
+
+	return f, nil
+}
@@ -0,0 +1,131 @@
+package authn_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+func TestAuthenticatorHtpasswd(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineAuthenticator("htpasswd")
+	require.NoError(t, err)
+	assert.Equal(t, "htpasswd", a.GetID())
+
+	dir, err := ioutil.TempDir("", "oathkeeper-htpasswd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".htpasswd")
+	writeUser := func(t *testing.T, username, password string, modTime time.Time) {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(path, []byte("# comment\n"+username+":"+string(hash)+"\n"), 0600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	writeUser(t, "alice", "secret", time.Now().Add(-time.Hour))
+
+	config := json.RawMessage(fmt.Sprintf(`{"path":"%s"}`, path))
+	request := func(username, password string) *http.Request {
+		r := &http.Request{Header: http.Header{}}
+		r.SetBasicAuth(username, password)
+		return r
+	}
+
+	t.Run("method=authenticate", func(t *testing.T) {
+		for k, tc := range []struct {
+			d         string
+			r         *http.Request
+			expectErr bool
+		}{
+			{
+				d:         "should not be responsible without basic auth",
+				r:         &http.Request{Header: http.Header{}},
+				expectErr: true,
+			},
+			{
+				d: "should pass with valid credentials",
+				r: request("alice", "secret"),
+			},
+			{
+				d:         "should fail because the password is wrong",
+				r:         request("alice", "not-the-secret"),
+				expectErr: true,
+			},
+			{
+				d:         "should fail because the user is unknown",
+				r:         request("bob", "secret"),
+				expectErr: true,
+			},
+		} {
+			t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+				session := new(authn.AuthenticationSession)
+				err := a.Authenticate(tc.r, session, config, nil)
+				if tc.expectErr {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, "alice", session.Subject)
+			})
+		}
+	})
+
+	t.Run("method=authenticate/case=should reload the file when it changes", func(t *testing.T) {
+		require.Error(t, a.Authenticate(request("bob", "other-secret"), new(authn.AuthenticationSession), config, nil))
+
+		writeUser(t, "bob", "other-secret", time.Now())
+
+		session := new(authn.AuthenticationSession)
+		require.NoError(t, a.Authenticate(request("bob", "other-secret"), session, config, nil))
+		assert.Equal(t, "bob", session.Subject)
+		require.Error(t, a.Authenticate(request("alice", "secret"), new(authn.AuthenticationSession), config, nil))
+	})
+
+	t.Run("method=authenticate/case=should take as long to reject unknown users as wrong passwords", func(t *testing.T) {
+		hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.DefaultCost)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(path, []byte("alice:"+string(hash)+"\n"), 0600))
+		now := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(path, now, now))
+
+		// Parses the file outside of the measurements.
+		require.NoError(t, a.Authenticate(request("alice", "secret"), new(authn.AuthenticationSession), config, nil))
+
+		measure := func(username string) time.Duration {
+			start := time.Now()
+			require.Error(t, a.Authenticate(request(username, "not-the-secret"), new(authn.AuthenticationSession), config, nil))
+			return time.Since(start)
+		}
+
+		known, unknown := measure("alice"), measure("mallory")
+		assert.True(t, unknown > known/2, "rejecting an unknown user took %s but rejecting a wrong password took %s", unknown, known)
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		viper.Set(configuration.ViperKeyAuthenticatorHtpasswdIsEnabled, true)
+		require.NoError(t, a.Validate(config))
+		require.Error(t, a.Validate(json.RawMessage(`{"path":"/does/not/exist"}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyAuthenticatorHtpasswdIsEnabled, false)
+		require.Error(t, a.Validate(config))
+	})
+}
@@ -0,0 +1,174 @@
+package authn
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/pipeline"
+)
+
+const (
+	mtlsSubjectFromCommonName   = "common_name"
+	mtlsSubjectFromDNSName      = "dns_name"
+	mtlsSubjectFromEmailAddress = "email_address"
+	mtlsSubjectFromURI          = "uri"
+)
+
+type AuthenticatorMTLSConfiguration struct {
+	CertificateAuthorities []string `json:"certificate_authorities"`
+	SubjectFrom            string   `json:"subject_from"`
+}
+
+// AuthenticatorMTLS authenticates requests using the client certificate presented during the TLS handshake. The
+// certificate must be signed by one of the configured certificate authorities.
+type AuthenticatorMTLS struct {
+	c configuration.Provider
+
+	sync.RWMutex
+	pools map[string]*x509.CertPool
+}
+
+func NewAuthenticatorMTLS(c configuration.Provider) *AuthenticatorMTLS {
+	return &AuthenticatorMTLS{
+		c:     c,
+		pools: map[string]*x509.CertPool{},
+	}
+}
+
+func (a *AuthenticatorMTLS) GetID() string {
+	return "mtls"
+}
+
+func (a *AuthenticatorMTLS) Validate(config json.RawMessage) error {
+	if !a.c.AuthenticatorIsEnabled(a.GetID()) {
+		return NewErrAuthenticatorNotEnabled(a)
+	}
+
+	cf, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.certPool(cf.CertificateAuthorities); err != nil {
+		return NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	return nil
+}
+
+func (a *AuthenticatorMTLS) Config(config json.RawMessage) (*AuthenticatorMTLSConfiguration, error) {
+	var c AuthenticatorMTLSConfiguration
+	if err := a.c.AuthenticatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	if c.SubjectFrom == "" {
+		c.SubjectFrom = mtlsSubjectFromCommonName
+	}
+
+	return &c, nil
+}
+
+func (a *AuthenticatorMTLS) Authenticate(r *http.Request, session *AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return errors.WithStack(ErrAuthenticatorNotResponsible)
+	}
+
+	cf, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	roots, err := a.certPool(cf.CertificateAuthorities)
+	if err != nil {
+		return NewErrAuthenticatorMisconfigured(a, err)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return helper.ErrUnauthorized.WithReasonf("The client certificate could not be verified: %s", err).WithTrace(err)
+	}
+
+	subject := mtlsSubject(cert, cf.SubjectFrom)
+	if subject == "" {
+		return helper.ErrUnauthorized.WithReasonf(`The client certificate does not contain a value for "%s".`, cf.SubjectFrom)
+	}
+
+	session.Subject = subject
+	session.Extra = map[string]interface{}{
+		"common_name":     cert.Subject.CommonName,
+		"serial_number":   cert.SerialNumber.String(),
+		"issuer":          cert.Issuer.String(),
+		"dns_names":       cert.DNSNames,
+		"email_addresses": cert.EmailAddresses,
+	}
+
+	return nil
+}
+
+func (a *AuthenticatorMTLS) certPool(paths []string) (*x509.CertPool, error) {
+	key := strings.Join(paths, "\n")
+
+	a.RLock()
+	pool, ok := a.pools[key]
+	a.RUnlock()
+	if ok {
+		return pool, nil
+	}
+
+	pool = x509.NewCertPool()
+	for _, path := range paths {
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf(`file "%s" does not contain any PEM encoded certificates`, path)
+		}
+	}
+
+	a.Lock()
+	a.pools[key] = pool
+	a.Unlock()
+
+	return pool, nil
+}
+
+func mtlsSubject(cert *x509.Certificate, from string) string {
+	switch from {
+	case mtlsSubjectFromDNSName:
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	case mtlsSubjectFromEmailAddress:
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+	case mtlsSubjectFromURI:
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	default:
+		return cert.Subject.CommonName
+	}
+
+	return ""
+}
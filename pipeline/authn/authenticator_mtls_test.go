@@ -0,0 +1,166 @@
+package authn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+func newTestCertificate(t *testing.T, tpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	if parent == nil {
+		parent, parentKey = tpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func TestAuthenticatorMTLS(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineAuthenticator("mtls")
+	require.NoError(t, err)
+	assert.Equal(t, "mtls", a.GetID())
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	ca, caKey := newTestCertificate(t, caTemplate, nil, nil)
+	other, _ := newTestCertificate(t, caTemplate, nil, nil)
+
+	client, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		Subject:        pkix.Name{CommonName: "my-service"},
+		DNSNames:       []string{"my-service.example.com"},
+		EmailAddresses: []string{"ops@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	caFile, err := ioutil.TempFile("", "oathkeeper-mtls-ca-*.pem")
+	require.NoError(t, err)
+	defer os.Remove(caFile.Name())
+	require.NoError(t, pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}))
+	require.NoError(t, caFile.Close())
+
+	t.Run("method=authenticate", func(t *testing.T) {
+		for k, tc := range []struct {
+			d             string
+			config        string
+			r             *http.Request
+			expectErr     bool
+			expectSubject string
+		}{
+			{
+				d:         "should not be responsible without TLS",
+				r:         &http.Request{Header: http.Header{}},
+				expectErr: true,
+			},
+			{
+				d:         "should not be responsible without client certificate",
+				r:         &http.Request{Header: http.Header{}, TLS: &tls.ConnectionState{}},
+				expectErr: true,
+			},
+			{
+				d:             "should use the common name",
+				r:             &http.Request{Header: http.Header{}, TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}},
+				expectSubject: "my-service",
+			},
+			{
+				d:             "should use the dns name",
+				config:        `{"subject_from":"dns_name"}`,
+				r:             &http.Request{Header: http.Header{}, TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}},
+				expectSubject: "my-service.example.com",
+			},
+			{
+				d:             "should use the email address",
+				config:        `{"subject_from":"email_address"}`,
+				r:             &http.Request{Header: http.Header{}, TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}},
+				expectSubject: "ops@example.com",
+			},
+			{
+				d:         "should fail because the certificate has no uri",
+				config:    `{"subject_from":"uri"}`,
+				r:         &http.Request{Header: http.Header{}, TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{client}}},
+				expectErr: true,
+			},
+			{
+				d:         "should fail because the certificate is signed by an unknown authority",
+				r:         &http.Request{Header: http.Header{}, TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{other}}},
+				expectErr: true,
+			},
+		} {
+			t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+				config := tc.config
+				if config == "" {
+					config = `{}`
+				}
+
+				var override map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(config), &override))
+				override["certificate_authorities"] = []string{caFile.Name()}
+				raw, err := json.Marshal(override)
+				require.NoError(t, err)
+
+				session := new(authn.AuthenticationSession)
+				err = a.Authenticate(tc.r, session, raw, nil)
+				if tc.expectErr {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectSubject, session.Subject)
+				assert.Equal(t, "my-service", session.Extra["common_name"])
+			})
+		}
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		config := json.RawMessage(fmt.Sprintf(`{"certificate_authorities":["%s"]}`, caFile.Name()))
+
+		viper.Set(configuration.ViperKeyAuthenticatorMTLSIsEnabled, true)
+		require.NoError(t, a.Validate(config))
+		require.Error(t, a.Validate(json.RawMessage(`{"certificate_authorities":["/does/not/exist.pem"]}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyAuthenticatorMTLSIsEnabled, false)
+		require.Error(t, a.Validate(config))
+	})
+}
@@ -0,0 +1,161 @@
+package authz
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/pipeline"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+// AuthorizerCompositeConfiguration represents a configuration for the all_of and any_of authorizers.
+type AuthorizerCompositeConfiguration struct {
+	Authorizers []AuthorizerCompositeHandler `json:"authorizers"`
+}
+
+// AuthorizerCompositeHandler is an authorizer combined by the all_of or any_of authorizer.
+type AuthorizerCompositeHandler struct {
+	Handler string          `json:"handler"`
+	Config  json.RawMessage `json:"config"`
+}
+
+type authorizerComposite struct {
+	c configuration.Provider
+	r Registry
+}
+
+func (a *authorizerComposite) validate(self Authorizer, config json.RawMessage) error {
+	if !a.c.AuthorizerIsEnabled(self.GetID()) {
+		return NewErrAuthorizerNotEnabled(self)
+	}
+
+	c, err := a.config(self, config)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range c.Authorizers {
+		child, err := a.r.PipelineAuthorizer(h.Handler)
+		if err != nil {
+			return NewErrAuthorizerMisconfigured(self, errors.Wrapf(err, `authorizer "%s" is unknown`, h.Handler))
+		}
+
+		if err := child.Validate(h.Config); err != nil {
+			return NewErrAuthorizerMisconfigured(self, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *authorizerComposite) config(self Authorizer, config json.RawMessage) (*AuthorizerCompositeConfiguration, error) {
+	var c AuthorizerCompositeConfiguration
+	if err := a.c.AuthorizerConfig(self.GetID(), config, &c); err != nil {
+		return nil, NewErrAuthorizerMisconfigured(self, err)
+	}
+
+	if len(c.Authorizers) == 0 {
+		return nil, NewErrAuthorizerMisconfigured(self, errors.New("at least one authorizer must be configured"))
+	}
+
+	return &c, nil
+}
+
+func (a *authorizerComposite) authorize(r *http.Request, session *authn.AuthenticationSession, h AuthorizerCompositeHandler, rl pipeline.Rule) error {
+	child, err := a.r.PipelineAuthorizer(h.Handler)
+	if err != nil {
+		return err
+	}
+	return child.Authorize(r, session, h.Config, rl)
+}
+
+// AuthorizerAllOf grants access if all configured authorizers grant access. The authorizers are evaluated in order
+// and the evaluation stops at the first authorizer denying access.
+type AuthorizerAllOf struct {
+	authorizerComposite
+}
+
+// NewAuthorizerAllOf creates a new AuthorizerAllOf.
+func NewAuthorizerAllOf(c configuration.Provider, r Registry) *AuthorizerAllOf {
+	return &AuthorizerAllOf{authorizerComposite{c: c, r: r}}
+}
+
+// GetID implements the Authorizer interface.
+func (a *AuthorizerAllOf) GetID() string {
+	return "all_of"
+}
+
+// Authorize implements the Authorizer interface.
+func (a *AuthorizerAllOf) Authorize(r *http.Request, session *authn.AuthenticationSession, config json.RawMessage, rl pipeline.Rule) error {
+	c, err := a.config(a, config)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range c.Authorizers {
+		if err := a.authorize(r, session, h, rl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate implements the Authorizer interface.
+func (a *AuthorizerAllOf) Validate(config json.RawMessage) error {
+	return a.validate(a, config)
+}
+
+// AuthorizerAnyOf grants access if at least one of the configured authorizers grants access. The authorizers are
+// evaluated in order and the evaluation stops at the first authorizer granting access. If all authorizers deny
+// access, the error of the last one is returned. Errors other than a denial (e.g. an unreachable remote) are returned
+// immediately instead of being masked by a later authorizer granting access.
+type AuthorizerAnyOf struct {
+	authorizerComposite
+}
+
+// NewAuthorizerAnyOf creates a new AuthorizerAnyOf.
+func NewAuthorizerAnyOf(c configuration.Provider, r Registry) *AuthorizerAnyOf {
+	return &AuthorizerAnyOf{authorizerComposite{c: c, r: r}}
+}
+
+// GetID implements the Authorizer interface.
+func (a *AuthorizerAnyOf) GetID() string {
+	return "any_of"
+}
+
+// Authorize implements the Authorizer interface.
+func (a *AuthorizerAnyOf) Authorize(r *http.Request, session *authn.AuthenticationSession, config json.RawMessage, rl pipeline.Rule) error {
+	c, err := a.config(a, config)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range c.Authorizers {
+		if err = a.authorize(r, session, h, rl); err == nil {
+			return nil
+		} else if !isDenial(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// isDenial returns true if err is a forbidden or unauthorized error, i.e. an authorizer denied access.
+func isDenial(err error) bool {
+	sc, ok := errors.Cause(err).(interface{ StatusCode() int })
+	if !ok {
+		return false
+	}
+	return sc.StatusCode() == helper.ErrForbidden.StatusCode() || sc.StatusCode() == helper.ErrUnauthorized.StatusCode()
+}
+
+// Validate implements the Authorizer interface.
+func (a *AuthorizerAnyOf) Validate(config json.RawMessage) error {
+	return a.validate(a, config)
+}
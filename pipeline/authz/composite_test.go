@@ -0,0 +1,103 @@
+package authz_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/viper"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/rule"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizerComposite(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	allOf, err := reg.PipelineAuthorizer("all_of")
+	require.NoError(t, err)
+	assert.Equal(t, "all_of", allOf.GetID())
+
+	anyOf, err := reg.PipelineAuthorizer("any_of")
+	require.NoError(t, err)
+	assert.Equal(t, "any_of", anyOf.GetID())
+
+	for k, tc := range []struct {
+		config   string
+		allOfErr bool
+		anyOfErr bool
+	}{
+		{config: `{"authorizers":[{"handler":"allow"},{"handler":"allow"}]}`},
+		{config: `{"authorizers":[{"handler":"allow"},{"handler":"deny"}]}`, allOfErr: true},
+		{config: `{"authorizers":[{"handler":"deny"},{"handler":"allow"}]}`, allOfErr: true},
+		{config: `{"authorizers":[{"handler":"deny"},{"handler":"deny"}]}`, allOfErr: true, anyOfErr: true},
+		{config: `{"authorizers":[{"handler":"any_of","config":{"authorizers":[{"handler":"deny"},{"handler":"allow"}]}}]}`},
+	} {
+		t.Run(fmt.Sprintf("method=authorize/case=%d", k), func(t *testing.T) {
+			viper.Set(configuration.ViperKeyAuthorizerAllOfIsEnabled, true)
+			viper.Set(configuration.ViperKeyAuthorizerAnyOfIsEnabled, true)
+			defer viper.Reset()
+
+			err := allOf.Authorize(nil, nil, json.RawMessage(tc.config), &rule.Rule{ID: "test"})
+			if tc.allOfErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			err = anyOf.Authorize(nil, nil, json.RawMessage(tc.config), &rule.Rule{ID: "test"})
+			if tc.anyOfErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("method=authorize/case=any_of should not mask errors other than a denial", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		viper.Set(configuration.ViperKeyAuthorizerAnyOfIsEnabled, true)
+		defer viper.Reset()
+
+		config := json.RawMessage(`{"authorizers":[{"handler":"remote_json","config":{"remote":"` + ts.URL + `","payload":"{}"}},{"handler":"allow"}]}`)
+		r := httptest.NewRequest("GET", "/", nil)
+		err := anyOf.Authorize(r, &authn.AuthenticationSession{}, config, &rule.Rule{ID: "test"})
+		require.Error(t, err)
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		config := json.RawMessage(`{"authorizers":[{"handler":"allow"},{"handler":"deny"}]}`)
+
+		// Whether a handler is enabled is cached until the configuration is reset.
+		viper.Reset()
+		viper.Set(configuration.ViperKeyAuthorizerAllOfIsEnabled, false)
+		require.Error(t, allOf.Validate(config))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyAuthorizerAllOfIsEnabled, true)
+		viper.Set(configuration.ViperKeyAuthorizerAllowIsEnabled, true)
+		require.Error(t, allOf.Validate(config), "deny is not enabled")
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyAuthorizerAllOfIsEnabled, true)
+		viper.Set(configuration.ViperKeyAuthorizerAllowIsEnabled, true)
+		viper.Set(configuration.ViperKeyAuthorizerDenyIsEnabled, true)
+		require.NoError(t, allOf.Validate(config))
+
+		require.Error(t, allOf.Validate(json.RawMessage(`{"authorizers":[]}`)))
+		require.Error(t, allOf.Validate(json.RawMessage(`{"authorizers":[{"handler":"unknown"}]}`)))
+		viper.Reset()
+	})
+}
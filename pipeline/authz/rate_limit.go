@@ -0,0 +1,230 @@
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/pipeline"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/x"
+)
+
+const (
+	rateLimitKeySubject = "subject"
+	rateLimitKeyIP      = "ip"
+
+	rateLimitSweepInterval = time.Minute
+
+	// rateLimitMaxBuckets limits the memory used by the buckets. If it is reached, buckets are evicted to make room
+	// for new callers.
+	rateLimitMaxBuckets = 100000
+)
+
+// AuthorizerRateLimitConfiguration represents a configuration for the rate_limit authorizer.
+type AuthorizerRateLimitConfiguration struct {
+	Requests int    `json:"requests"`
+	Period   string `json:"period"`
+	Burst    int    `json:"burst"`
+	Key      string `json:"key"`
+}
+
+// ErrTooManyRequests is returned by the rate_limit authorizer if the caller exceeded its quota.
+type ErrTooManyRequests struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrTooManyRequests) Error() string {
+	return "Too many requests"
+}
+
+func (e *ErrTooManyRequests) StatusCode() int {
+	return http.StatusTooManyRequests
+}
+
+func (e *ErrTooManyRequests) Status() string {
+	return http.StatusText(http.StatusTooManyRequests)
+}
+
+func (e *ErrTooManyRequests) Reason() string {
+	return fmt.Sprintf("The rate limit of this resource was exceeded, retry in %s.", e.RetryAfter)
+}
+
+// Header returns the headers which should be sent alongside the error response.
+func (e *ErrTooManyRequests) Header() http.Header {
+	return http.Header{"Retry-After": {strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds())))}}
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+
+	rate  float64
+	burst float64
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// AuthorizerRateLimit implements the Authorizer interface.
+type AuthorizerRateLimit struct {
+	c configuration.Provider
+
+	sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewAuthorizerRateLimit creates a new AuthorizerRateLimit.
+func NewAuthorizerRateLimit(c configuration.Provider) *AuthorizerRateLimit {
+	return &AuthorizerRateLimit{
+		c:         c,
+		buckets:   map[string]*tokenBucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// GetID implements the Authorizer interface.
+func (a *AuthorizerRateLimit) GetID() string {
+	return "rate_limit"
+}
+
+// Authorize implements the Authorizer interface.
+func (a *AuthorizerRateLimit) Authorize(r *http.Request, session *authn.AuthenticationSession, config json.RawMessage, rl pipeline.Rule) error {
+	c, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	period, err := time.ParseDuration(c.Period)
+	if err != nil {
+		return NewErrAuthorizerMisconfigured(a, err)
+	}
+
+	key := session.Subject
+	if c.Key == rateLimitKeyIP {
+		ipFilter, err := a.c.AccessRuleIPFilter()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		// The X-Forwarded-For header is only respected for trusted proxies, otherwise callers could pick a new key
+		// for each request.
+		ip, err := x.ClientIP(r, ipFilter.TrustedProxies)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		key = ip.String()
+	}
+
+	// The limit is part of the key so that several rate_limit authorizers in one rule (e.g. combined with all_of)
+	// keep separate buckets.
+	key = fmt.Sprintf("%s|%d/%s|%d|%s|%s", rl.GetID(), c.Requests, period, c.Burst, c.Key, key)
+	rate := float64(c.Requests) / period.Seconds()
+	if retryAfter, ok := a.take(key, rate, float64(c.Burst), time.Now()); !ok {
+		return errors.WithStack(&ErrTooManyRequests{RetryAfter: retryAfter})
+	}
+
+	return nil
+}
+
+// take removes a token from the bucket identified by key. If the bucket is empty, it returns false and the duration
+// after which the next token becomes available.
+func (a *AuthorizerRateLimit) take(key string, rate, burst float64, now time.Time) (time.Duration, bool) {
+	a.Lock()
+	defer a.Unlock()
+
+	if now.Sub(a.lastSweep) > rateLimitSweepInterval {
+		a.sweep(now)
+	}
+
+	b, ok := a.buckets[key]
+	if !ok {
+		if len(a.buckets) >= rateLimitMaxBuckets {
+			a.evict(now)
+		}
+
+		b = &tokenBucket{tokens: burst, last: now, rate: rate, burst: burst}
+		a.buckets[key] = b
+	}
+
+	b.refill(now)
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / b.rate * float64(time.Second)), false
+	}
+
+	b.tokens--
+	return 0, true
+}
+
+// sweep removes all buckets which are full again and would therefore behave exactly like a new bucket.
+func (a *AuthorizerRateLimit) sweep(now time.Time) {
+	for key, b := range a.buckets {
+		b.refill(now)
+		if b.tokens >= b.burst {
+			delete(a.buckets, key)
+		}
+	}
+	a.lastSweep = now
+}
+
+// evict removes the buckets which are full again and, if that is not enough, arbitrary buckets until there is room for
+// a new bucket.
+func (a *AuthorizerRateLimit) evict(now time.Time) {
+	a.sweep(now)
+	for key := range a.buckets {
+		if len(a.buckets) < rateLimitMaxBuckets {
+			break
+		}
+		delete(a.buckets, key)
+	}
+}
+
+// Validate implements the Authorizer interface.
+func (a *AuthorizerRateLimit) Validate(config json.RawMessage) error {
+	if !a.c.AuthorizerIsEnabled(a.GetID()) {
+		return NewErrAuthorizerNotEnabled(a)
+	}
+
+	_, err := a.Config(config)
+	return err
+}
+
+// Config merges config and the authorizer's configuration and validates the
+// resulting configuration. It reports an error if the configuration is invalid.
+func (a *AuthorizerRateLimit) Config(config json.RawMessage) (*AuthorizerRateLimitConfiguration, error) {
+	var c AuthorizerRateLimitConfiguration
+	if err := a.c.AuthorizerConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrAuthorizerMisconfigured(a, err)
+	}
+
+	if c.Period == "" {
+		c.Period = "1s"
+	}
+
+	if period, err := time.ParseDuration(c.Period); err != nil {
+		return nil, NewErrAuthorizerMisconfigured(a, err)
+	} else if period <= 0 {
+		return nil, NewErrAuthorizerMisconfigured(a, errors.New("period must be greater than zero"))
+	}
+
+	if c.Burst == 0 {
+		c.Burst = c.Requests
+	}
+
+	if c.Key == "" {
+		c.Key = rateLimitKeySubject
+	}
+
+	return &c, nil
+}
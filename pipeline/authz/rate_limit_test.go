@@ -0,0 +1,159 @@
+package authz_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/viper"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	. "github.com/ory/oathkeeper/pipeline/authz"
+	"github.com/ory/oathkeeper/rule"
+)
+
+func TestAuthorizerRateLimitAuthorize(t *testing.T) {
+	p := configuration.NewViperProvider(logrusx.New("", ""))
+	a := NewAuthorizerRateLimit(p)
+
+	authorize := func(subject, ip, id string, config string) error {
+		r := &http.Request{Header: http.Header{}, RemoteAddr: ip + ":1234"}
+		return a.Authorize(r, &authn.AuthenticationSession{Subject: subject}, json.RawMessage(config), &rule.Rule{ID: id})
+	}
+
+	t.Run("case=invalid configuration", func(t *testing.T) {
+		require.Error(t, authorize("alice", "127.0.0.1", "rule-1", `{}`))
+		require.Error(t, authorize("alice", "127.0.0.1", "rule-1", `{"requests":1,"period":"0s"}`))
+		require.Error(t, authorize("alice", "127.0.0.1", "rule-1", `{"requests":1,"key":"foo"}`))
+	})
+
+	t.Run("case=limits per subject", func(t *testing.T) {
+		config := `{"requests":2,"period":"1h"}`
+		require.NoError(t, authorize("alice", "127.0.0.1", "rule-1", config))
+		require.NoError(t, authorize("alice", "127.0.0.2", "rule-1", config))
+
+		err := authorize("alice", "127.0.0.3", "rule-1", config)
+		require.Error(t, err)
+
+		var e *ErrTooManyRequests
+		require.True(t, errors.As(err, &e))
+		assert.Equal(t, http.StatusTooManyRequests, e.StatusCode())
+		assert.Equal(t, "1800", e.Header().Get("Retry-After"))
+
+		require.NoError(t, authorize("bob", "127.0.0.1", "rule-1", config))
+		require.NoError(t, authorize("alice", "127.0.0.1", "rule-2", config))
+	})
+
+	t.Run("case=limits per ip", func(t *testing.T) {
+		config := `{"requests":1,"period":"1h","key":"ip"}`
+		require.NoError(t, authorize("alice", "127.0.0.1", "rule-3", config))
+		require.Error(t, authorize("bob", "127.0.0.1", "rule-3", config))
+		require.NoError(t, authorize("alice", "127.0.0.2", "rule-3", config))
+	})
+
+	t.Run("case=ignores spoofed headers", func(t *testing.T) {
+		config := `{"requests":1,"period":"1h","key":"ip"}`
+		for k, ip := range []string{"", "10.0.0.1", "10.0.0.2"} {
+			r := &http.Request{Header: http.Header{}, RemoteAddr: "127.0.0.5:1234"}
+			r.Header.Set("X-Forwarded-For", ip)
+			r.Header.Set("X-Real-Ip", ip)
+
+			err := a.Authorize(r, &authn.AuthenticationSession{}, json.RawMessage(config), &rule.Rule{ID: "rule-5"})
+			if k == 0 {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err, "%s", ip)
+			}
+		}
+	})
+
+	t.Run("case=respects the forwarded header of trusted proxies", func(t *testing.T) {
+		viper.Set(configuration.ViperKeyAccessRuleIPFilterTrustedProxies, []string{"127.0.0.6"})
+		defer viper.Set(configuration.ViperKeyAccessRuleIPFilterTrustedProxies, []string{})
+		a := NewAuthorizerRateLimit(configuration.NewViperProvider(logrusx.New("", "")))
+
+		config := `{"requests":1,"period":"1h","key":"ip"}`
+		for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+			r := &http.Request{Header: http.Header{"X-Forwarded-For": {ip}}, RemoteAddr: "127.0.0.6:1234"}
+			require.NoError(t, a.Authorize(r, &authn.AuthenticationSession{}, json.RawMessage(config), &rule.Rule{ID: "rule-6"}))
+		}
+	})
+
+	t.Run("case=burst", func(t *testing.T) {
+		config := `{"requests":1,"period":"1h","burst":3}`
+		for i := 0; i < 3; i++ {
+			require.NoError(t, authorize("alice", "127.0.0.1", "rule-4", config))
+		}
+		require.Error(t, authorize("alice", "127.0.0.1", "rule-4", config))
+	})
+
+	t.Run("case=keeps separate buckets for different limits in one rule", func(t *testing.T) {
+		perMinute := `{"requests":2,"period":"1m"}`
+		perHour := `{"requests":3,"period":"1h"}`
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, authorize("alice", "127.0.0.1", "rule-7", perMinute))
+			require.NoError(t, authorize("alice", "127.0.0.1", "rule-7", perHour))
+		}
+		require.Error(t, authorize("alice", "127.0.0.1", "rule-7", perMinute))
+		require.NoError(t, authorize("alice", "127.0.0.1", "rule-7", perHour))
+		require.Error(t, authorize("alice", "127.0.0.1", "rule-7", perHour))
+
+		require.NoError(t, authorize("alice", "127.0.0.1", "rule-7", `{"requests":2,"period":"1m","burst":3}`))
+	})
+}
+
+func TestAuthorizerRateLimitValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		config  json.RawMessage
+		wantErr bool
+	}{
+		{
+			name:    "disabled",
+			config:  json.RawMessage(`{"requests":1}`),
+			wantErr: true,
+		},
+		{
+			name:    "empty configuration",
+			enabled: true,
+			config:  json.RawMessage(`{}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid period",
+			enabled: true,
+			config:  json.RawMessage(`{"requests":1,"period":"foo"}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid requests",
+			enabled: true,
+			config:  json.RawMessage(`{"requests":0}`),
+			wantErr: true,
+		},
+		{
+			name:    "valid configuration",
+			enabled: true,
+			config:  json.RawMessage(`{"requests":10,"period":"1m","burst":5,"key":"ip"}`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := configuration.NewViperProvider(logrusx.New("", ""))
+			a := NewAuthorizerRateLimit(p)
+			viper.Set(configuration.ViperKeyAuthorizerRateLimitIsEnabled, tt.enabled)
+			if err := a.Validate(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -60,7 +60,7 @@ func (a *AuthorizerRemote) Authorize(r *http.Request, session *authn.Authenticat
 		write.CloseWithError(errors.Wrapf(err, `could not pipe request body in rule "%s"`, rl.GetID()))
 	}()
 
-	req, err := http.NewRequest("POST", c.Remote, read)
+	req, err := http.NewRequestWithContext(r.Context(), "POST", c.Remote, read)
 	if err != nil {
 		return errors.WithStack(err)
 	}
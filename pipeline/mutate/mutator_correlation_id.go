@@ -0,0 +1,86 @@
+package mutate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/pipeline"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+const defaultCorrelationIDHeader = "X-Correlation-ID"
+
+type correlationIDContextKey struct{}
+
+// CorrelationID is the correlation ID of a request together with the name of the header it is transported in.
+type CorrelationID struct {
+	Header string
+	ID     string
+}
+
+// CorrelationIDFromContext returns the correlation ID which was set by the correlation_id mutator, if any.
+func CorrelationIDFromContext(ctx context.Context) (*CorrelationID, bool) {
+	c, ok := ctx.Value(correlationIDContextKey{}).(*CorrelationID)
+	return c, ok
+}
+
+type MutatorCorrelationIDConfig struct {
+	HeaderName string `json:"header_name"`
+}
+
+// MutatorCorrelationID propagates the correlation ID of the incoming request or generates a new one if the request
+// has none. The ID is forwarded to the upstream, stored in the request's context and returned to the client.
+type MutatorCorrelationID struct {
+	c configuration.Provider
+}
+
+func NewMutatorCorrelationID(c configuration.Provider) *MutatorCorrelationID {
+	return &MutatorCorrelationID{c: c}
+}
+
+func (a *MutatorCorrelationID) GetID() string {
+	return "correlation_id"
+}
+
+func (a *MutatorCorrelationID) Mutate(r *http.Request, session *authn.AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	cfg, err := a.config(config)
+	if err != nil {
+		return err
+	}
+
+	id := r.Header.Get(cfg.HeaderName)
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	session.SetHeader(cfg.HeaderName, id)
+	*r = *r.WithContext(context.WithValue(r.Context(), correlationIDContextKey{}, &CorrelationID{Header: cfg.HeaderName, ID: id}))
+
+	return nil
+}
+
+func (a *MutatorCorrelationID) Validate(config json.RawMessage) error {
+	if !a.c.MutatorIsEnabled(a.GetID()) {
+		return NewErrMutatorNotEnabled(a)
+	}
+
+	_, err := a.config(config)
+	return err
+}
+
+func (a *MutatorCorrelationID) config(config json.RawMessage) (*MutatorCorrelationIDConfig, error) {
+	var c MutatorCorrelationIDConfig
+	if err := a.c.MutatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrMutatorMisconfigured(a, err)
+	}
+
+	if c.HeaderName == "" {
+		c.HeaderName = defaultCorrelationIDHeader
+	}
+
+	return &c, nil
+}
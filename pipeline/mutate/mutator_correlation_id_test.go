@@ -0,0 +1,81 @@
+package mutate_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ory/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/pipeline/mutate"
+	"github.com/ory/oathkeeper/rule"
+)
+
+func TestMutatorCorrelationID(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineMutator("correlation_id")
+	require.NoError(t, err)
+	assert.Equal(t, "correlation_id", a.GetID())
+
+	t.Run("method=mutate", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			config       json.RawMessage
+			header       http.Header
+			expectHeader string
+			expectID     string
+		}{
+			"generates id": {
+				header:       http.Header{},
+				expectHeader: "X-Correlation-ID",
+			},
+			"propagates id": {
+				header:       http.Header{"X-Correlation-Id": {"foo"}},
+				expectHeader: "X-Correlation-ID",
+				expectID:     "foo",
+			},
+			"custom header": {
+				config:       json.RawMessage(`{"header_name":"X-Request-ID"}`),
+				header:       http.Header{"X-Request-Id": {"bar"}, "X-Correlation-Id": {"foo"}},
+				expectHeader: "X-Request-ID",
+				expectID:     "bar",
+			},
+		} {
+			t.Run("case="+name, func(t *testing.T) {
+				r := &http.Request{Header: tc.header}
+				s := &authn.AuthenticationSession{}
+				require.NoError(t, a.Mutate(r, s, tc.config, &rule.Rule{ID: "test-rule"}))
+
+				id := s.Header.Get(tc.expectHeader)
+				if tc.expectID == "" {
+					_, err := uuid.Parse(id)
+					require.NoError(t, err)
+				} else {
+					assert.Equal(t, tc.expectID, id)
+				}
+
+				c, ok := mutate.CorrelationIDFromContext(r.Context())
+				require.True(t, ok)
+				assert.Equal(t, tc.expectHeader, c.Header)
+				assert.Equal(t, id, c.ID)
+			})
+		}
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		viper.Set(configuration.ViperKeyMutatorCorrelationIDIsEnabled, true)
+		require.NoError(t, a.Validate(nil))
+		require.Error(t, a.Validate(json.RawMessage(`{"foo":"bar"}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyMutatorCorrelationIDIsEnabled, false)
+		require.Error(t, a.Validate(nil))
+	})
+}
@@ -163,7 +163,7 @@ func (a *MutatorHydrator) Mutate(r *http.Request, session *authn.AuthenticationS
 	} else if _, err := url.ParseRequestURI(cfg.Api.URL); err != nil {
 		return errors.New(ErrInvalidAPIURL)
 	}
-	req, err := http.NewRequest("POST", cfg.Api.URL, &b)
+	req, err := http.NewRequestWithContext(r.Context(), "POST", cfg.Api.URL, &b)
 	if err != nil {
 		return errors.WithStack(err)
 	}
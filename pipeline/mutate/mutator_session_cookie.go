@@ -0,0 +1,105 @@
+package mutate
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/pipeline"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+type MutatorSessionCookieConfig struct {
+	CookieName string `json:"cookie_name"`
+	Secret     string `json:"secret"`
+	IssuerURL  string `json:"issuer_url"`
+	TTL        string `json:"ttl"`
+}
+
+// MutatorSessionCookie forwards the authentication session to the upstream as a cookie which contains a short-lived
+// JSON Web Token signed with HS256.
+type MutatorSessionCookie struct {
+	c configuration.Provider
+}
+
+func NewMutatorSessionCookie(c configuration.Provider) *MutatorSessionCookie {
+	return &MutatorSessionCookie{c: c}
+}
+
+func (a *MutatorSessionCookie) GetID() string {
+	return "session_cookie"
+}
+
+func (a *MutatorSessionCookie) Mutate(r *http.Request, session *authn.AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	cfg, err := a.config(config)
+	if err != nil {
+		return err
+	}
+
+	ttl, err := time.ParseDuration(cfg.TTL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now().UTC()
+	claims := jwt.MapClaims{
+		"sub": session.Subject,
+		"ext": session.Extra,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+		"jti": uuid.New().String(),
+	}
+	if cfg.IssuerURL != "" {
+		claims["iss"] = cfg.IssuerURL
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.Secret))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req := http.Request{Header: map[string][]string{}}
+	req.AddCookie(&http.Cookie{Name: cfg.CookieName, Value: token})
+	for _, cookie := range r.Cookies() {
+		// A client must not be able to pass its own session cookie to the upstream.
+		if cookie.Name != cfg.CookieName {
+			req.AddCookie(cookie)
+		}
+	}
+
+	session.SetHeader(cookieHeader, req.Header.Get(cookieHeader))
+
+	return nil
+}
+
+func (a *MutatorSessionCookie) Validate(config json.RawMessage) error {
+	if !a.c.MutatorIsEnabled(a.GetID()) {
+		return NewErrMutatorNotEnabled(a)
+	}
+
+	_, err := a.config(config)
+	return err
+}
+
+func (a *MutatorSessionCookie) config(config json.RawMessage) (*MutatorSessionCookieConfig, error) {
+	var c MutatorSessionCookieConfig
+	if err := a.c.MutatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrMutatorMisconfigured(a, err)
+	}
+
+	if c.CookieName == "" {
+		c.CookieName = "oathkeeper_session"
+	}
+
+	if c.TTL == "" {
+		c.TTL = "1m"
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,73 @@
+package mutate_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/ory/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/rule"
+)
+
+func TestMutatorSessionCookie(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineMutator("session_cookie")
+	require.NoError(t, err)
+	assert.Equal(t, "session_cookie", a.GetID())
+
+	secret := "a-secret-which-is-at-least-32-characters-long"
+	config := json.RawMessage(`{"secret":"` + secret + `","issuer_url":"https://oathkeeper/"}`)
+
+	t.Run("method=mutate", func(t *testing.T) {
+		r := &http.Request{Header: http.Header{}}
+		r.AddCookie(&http.Cookie{Name: "oathkeeper_session", Value: "forged"})
+		r.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+
+		s := &authn.AuthenticationSession{Subject: "foo", Extra: map[string]interface{}{"role": "admin"}}
+		require.NoError(t, a.Mutate(r, s, config, &rule.Rule{ID: "test-rule"}))
+
+		forwarded := &http.Request{Header: s.Header}
+		theme, err := forwarded.Cookie("theme")
+		require.NoError(t, err)
+		assert.Equal(t, "dark", theme.Value)
+
+		cookies := forwarded.Cookies()
+		require.Len(t, cookies, 2)
+
+		session, err := forwarded.Cookie("oathkeeper_session")
+		require.NoError(t, err)
+		assert.NotEqual(t, "forged", session.Value)
+
+		token, err := jwt.Parse(session.Value, func(token *jwt.Token) (interface{}, error) {
+			_, ok := token.Method.(*jwt.SigningMethodHMAC)
+			require.True(t, ok)
+			return []byte(secret), nil
+		})
+		require.NoError(t, err)
+
+		claims := token.Claims.(jwt.MapClaims)
+		assert.Equal(t, "foo", claims["sub"])
+		assert.Equal(t, "https://oathkeeper/", claims["iss"])
+		assert.Equal(t, map[string]interface{}{"role": "admin"}, claims["ext"])
+		assert.EqualValues(t, claims["iat"].(float64)+60, claims["exp"])
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		viper.Set(configuration.ViperKeyMutatorSessionCookieIsEnabled, true)
+		require.NoError(t, a.Validate(config))
+		require.Error(t, a.Validate(json.RawMessage(`{"secret":"too-short"}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyMutatorSessionCookieIsEnabled, false)
+		require.Error(t, a.Validate(config))
+	})
+}
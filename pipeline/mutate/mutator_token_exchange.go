@@ -0,0 +1,241 @@
+package mutate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/ristretto"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/httpx"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/pipeline"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/x"
+)
+
+const (
+	tokenExchangeGrantType        = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenExchangeAccessTokenType  = "urn:ietf:params:oauth:token-type:access_token"
+	tokenExchangeCacheExpiryDelta = 10 * time.Second
+)
+
+type MutatorTokenExchangeConfig struct {
+	TokenURL           string                      `json:"token_url"`
+	ClientID           string                      `json:"client_id"`
+	ClientSecret       string                      `json:"client_secret"`
+	Audience           string                      `json:"audience"`
+	Scope              []string                    `json:"scope"`
+	SubjectTokenType   string                      `json:"subject_token_type"`
+	RequestedTokenType string                      `json:"requested_token_type"`
+	TokenFrom          *helper.BearerTokenLocation `json:"token_from"`
+	Cache              cacheConfig                 `json:"cache"`
+}
+
+type tokenExchangeResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type mutatorTokenExchangeDependencies interface {
+	x.RegistryLogger
+}
+
+// MutatorTokenExchange exchanges the token of the incoming request for a token issued for the upstream, as specified
+// in RFC 8693, and forwards it in the Authorization header.
+type MutatorTokenExchange struct {
+	c      configuration.Provider
+	d      mutatorTokenExchangeDependencies
+	client *http.Client
+
+	tokenCache *ristretto.Cache
+}
+
+func NewMutatorTokenExchange(c configuration.Provider, d mutatorTokenExchangeDependencies) *MutatorTokenExchange {
+	cache, _ := ristretto.NewCache(&ristretto.Config{
+		NumCounters: 10000,
+		// Allocate a max of 32MB
+		MaxCost:     1 << 25,
+		BufferItems: 64,
+	})
+	return &MutatorTokenExchange{c: c, d: d, client: httpx.NewResilientClientLatencyToleranceSmall(nil), tokenCache: cache}
+}
+
+func (a *MutatorTokenExchange) GetID() string {
+	return "token_exchange"
+}
+
+func (a *MutatorTokenExchange) cacheKey(cfg *MutatorTokenExchangeConfig, token string) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%x", cfg.TokenURL, cfg.ClientID, cfg.Audience, strings.Join(cfg.Scope, " "), sha256.Sum256([]byte(token)))
+}
+
+func (a *MutatorTokenExchange) Mutate(r *http.Request, session *authn.AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	cfg, err := a.Config(config)
+	if err != nil {
+		return err
+	}
+
+	token := helper.BearerTokenFromRequest(r, cfg.TokenFrom)
+	if token == "" {
+		return errors.WithStack(helper.ErrUnauthorized.WithReason("The request does not contain a token which could be exchanged."))
+	}
+
+	key := a.cacheKey(cfg, token)
+	if cfg.Cache.Enabled {
+		if item, found := a.tokenCache.Get(key); found {
+			removeSubjectToken(r, session, cfg.TokenFrom)
+			session.SetHeader("Authorization", "Bearer "+item.(string))
+			return nil
+		}
+	}
+
+	res, err := a.exchange(r.Context(), cfg, token)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Cache.Enabled {
+		ttl := cfg.Cache.ttl
+		if res.ExpiresIn > 0 {
+			if expiresIn := time.Duration(res.ExpiresIn)*time.Second - tokenExchangeCacheExpiryDelta; expiresIn < ttl {
+				ttl = expiresIn
+			}
+		}
+
+		if ttl > 0 && !a.tokenCache.SetWithTTL(key, res.AccessToken, 0, ttl) {
+			a.d.Logger().Debug("Cache reject item")
+		}
+	}
+
+	removeSubjectToken(r, session, cfg.TokenFrom)
+	session.SetHeader("Authorization", "Bearer "+res.AccessToken)
+	return nil
+}
+
+// removeSubjectToken removes the token which was exchanged from the request, so that the upstream never receives it.
+// Tokens sent in the Authorization header are replaced by the exchanged token instead.
+func removeSubjectToken(r *http.Request, session *authn.AuthenticationSession, from *helper.BearerTokenLocation) {
+	if from == nil {
+		return
+	}
+
+	switch {
+	case from.Header != nil:
+		if !strings.EqualFold(*from.Header, "Authorization") {
+			session.SetHeader(*from.Header, "")
+		}
+	case from.QueryParameter != nil:
+		if r.URL != nil {
+			query := r.URL.Query()
+			query.Del(*from.QueryParameter)
+			r.URL.RawQuery = query.Encode()
+		}
+	case from.Cookie != nil:
+		// Another mutator might already have changed the cookies which are forwarded.
+		header := r.Header
+		if _, ok := session.Header["Cookie"]; ok {
+			header = session.Header
+		}
+
+		var cookies []string
+		for _, cookie := range (&http.Request{Header: header}).Cookies() {
+			if cookie.Name != *from.Cookie {
+				cookies = append(cookies, cookie.String())
+			}
+		}
+		session.SetHeader("Cookie", strings.Join(cookies, "; "))
+	}
+}
+
+func (a *MutatorTokenExchange) exchange(ctx context.Context, cfg *MutatorTokenExchangeConfig, token string) (*tokenExchangeResponse, error) {
+	form := url.Values{
+		"grant_type":         {tokenExchangeGrantType},
+		"subject_token":      {token},
+		"subject_token_type": {cfg.SubjectTokenType},
+	}
+	if cfg.Audience != "" {
+		form.Set("audience", cfg.Audience)
+	}
+	if len(cfg.Scope) > 0 {
+		form.Set("scope", strings.Join(cfg.Scope, " "))
+	}
+	if cfg.RequestedTokenType != "" {
+		form.Set("requested_token_type", cfg.RequestedTokenType)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set(contentTypeHeaderKey, "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest:
+		// The token endpoint responds with invalid_grant if the subject token is invalid or expired.
+		return nil, errors.WithStack(helper.ErrForbidden.WithReason("The token exchange was rejected by the authorization server."))
+	case http.StatusUnauthorized:
+		return nil, errors.New(ErrInvalidCredentials)
+	default:
+		return nil, errors.New(ErrNon200ResponseFromAPI)
+	}
+
+	var tr tokenExchangeResponse
+	if err := json.NewDecoder(res.Body).Decode(&tr); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if tr.AccessToken == "" {
+		return nil, errors.New(ErrMalformedResponseFromUpstreamAPI)
+	}
+
+	return &tr, nil
+}
+
+func (a *MutatorTokenExchange) Validate(config json.RawMessage) error {
+	if !a.c.MutatorIsEnabled(a.GetID()) {
+		return NewErrMutatorNotEnabled(a)
+	}
+
+	_, err := a.Config(config)
+	return err
+}
+
+func (a *MutatorTokenExchange) Config(config json.RawMessage) (*MutatorTokenExchangeConfig, error) {
+	var c MutatorTokenExchangeConfig
+	if err := a.c.MutatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrMutatorMisconfigured(a, err)
+	}
+
+	if c.SubjectTokenType == "" {
+		c.SubjectTokenType = tokenExchangeAccessTokenType
+	}
+
+	if c.Cache.Enabled {
+		c.Cache.ttl = time.Minute
+		if c.Cache.TTL != "" {
+			var err error
+			if c.Cache.ttl, err = time.ParseDuration(c.Cache.TTL); err != nil {
+				return nil, NewErrMutatorMisconfigured(a, err)
+			}
+		}
+	}
+
+	return &c, nil
+}
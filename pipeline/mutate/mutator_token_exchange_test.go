@@ -0,0 +1,176 @@
+package mutate_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/viper"
+	"github.com/ory/x/urlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/rule"
+)
+
+func TestMutatorTokenExchange(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineMutator("token_exchange")
+	require.NoError(t, err)
+	assert.Equal(t, "token_exchange", a.GetID())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "urn:ietf:params:oauth:token-type:access_token", r.PostForm.Get("subject_token_type"))
+
+		if r.PostForm.Get("subject_token") != "end-user-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"upstream-token-for-%s","token_type":"Bearer","expires_in":300}`, r.PostForm.Get("audience"))
+	}))
+	defer ts.Close()
+
+	t.Run("method=mutate", func(t *testing.T) {
+		for k, tc := range []struct {
+			d      string
+			config string
+			header http.Header
+			expect string
+			err    bool
+		}{
+			{
+				d:      "should exchange the token",
+				config: `{"audience":"orders"}`,
+				header: http.Header{"Authorization": {"Bearer end-user-token"}},
+				expect: "Bearer upstream-token-for-orders",
+			},
+			{
+				d:      "should exchange the token from a custom location",
+				config: `{"audience":"orders","token_from":{"header":"X-Token"}}`,
+				header: http.Header{"X-Token": {"end-user-token"}},
+				expect: "Bearer upstream-token-for-orders",
+			},
+			{
+				d:   "should fail without token",
+				err: true,
+			},
+			{
+				d:      "should fail because the exchange is rejected",
+				header: http.Header{"Authorization": {"Bearer invalid-token"}},
+				err:    true,
+			},
+			{
+				d:      "should fail because the client credentials are invalid",
+				config: `{"client_secret":"not-the-secret"}`,
+				header: http.Header{"Authorization": {"Bearer end-user-token"}},
+				err:    true,
+			},
+		} {
+			t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+				var override map[string]interface{}
+				if tc.config != "" {
+					require.NoError(t, json.Unmarshal([]byte(tc.config), &override))
+				} else {
+					override = map[string]interface{}{}
+				}
+
+				config := map[string]interface{}{
+					"token_url":     ts.URL,
+					"client_id":     "client",
+					"client_secret": "secret",
+				}
+				for k, v := range override {
+					config[k] = v
+				}
+				raw, err := json.Marshal(config)
+				require.NoError(t, err)
+
+				header := tc.header
+				if header == nil {
+					header = http.Header{}
+				}
+
+				s := &authn.AuthenticationSession{Subject: "foo"}
+				err = a.Mutate(&http.Request{Header: header}, s, raw, &rule.Rule{ID: "test-rule"})
+				if tc.err {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expect, s.Header.Get("Authorization"))
+			})
+		}
+	})
+
+	t.Run("method=mutate/case=should remove the subject token from the request", func(t *testing.T) {
+		for k, tc := range []struct {
+			tokenFrom string
+			r         *http.Request
+		}{
+			{
+				tokenFrom: `{"header":"X-Token"}`,
+				r:         &http.Request{URL: urlx.ParseOrPanic("https://api/orders?page=2"), Header: http.Header{"X-Token": {"end-user-token"}}},
+			},
+			{
+				tokenFrom: `{"query_parameter":"token"}`,
+				r:         &http.Request{URL: urlx.ParseOrPanic("https://api/orders?page=2&token=end-user-token"), Header: http.Header{}},
+			},
+			{
+				tokenFrom: `{"cookie":"session"}`,
+				r:         &http.Request{URL: urlx.ParseOrPanic("https://api/orders?page=2"), Header: http.Header{"Cookie": {"theme=dark; session=end-user-token"}}},
+			},
+		} {
+			t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+				config := json.RawMessage(fmt.Sprintf(`{"token_url":"%s","client_id":"client","client_secret":"secret","audience":"orders","token_from":%s}`, ts.URL, tc.tokenFrom))
+
+				s := &authn.AuthenticationSession{Subject: "foo"}
+				require.NoError(t, a.Mutate(tc.r, s, config, &rule.Rule{ID: "test-rule"}))
+
+				// This is what the proxy does before forwarding the request.
+				for h := range s.Header {
+					tc.r.Header.Set(h, s.Header.Get(h))
+				}
+
+				assert.Equal(t, "Bearer upstream-token-for-orders", tc.r.Header.Get("Authorization"))
+				assert.Equal(t, "page=2", tc.r.URL.RawQuery)
+				for h := range tc.r.Header {
+					assert.NotContains(t, tc.r.Header.Get(h), "end-user-token", h)
+				}
+				if c, err := tc.r.Cookie("theme"); err == nil {
+					assert.Equal(t, "dark", c.Value)
+				}
+			})
+		}
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		config := json.RawMessage(fmt.Sprintf(`{"token_url":"%s","client_id":"client","client_secret":"secret"}`, ts.URL))
+
+		viper.Set(configuration.ViperKeyMutatorTokenExchangeIsEnabled, true)
+		require.NoError(t, a.Validate(config))
+		require.Error(t, a.Validate(json.RawMessage(`{"client_id":"client"}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyMutatorTokenExchangeIsEnabled, false)
+		require.Error(t, a.Validate(config))
+	})
+}
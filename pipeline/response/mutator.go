@@ -0,0 +1,38 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/herodot"
+
+	"github.com/ory/oathkeeper/pipeline"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+var ErrMutatorNotEnabled = herodot.DefaultError{
+	ErrorField:  "response mutator matching this route is misconfigured or disabled",
+	CodeField:   http.StatusInternalServerError,
+	StatusField: http.StatusText(http.StatusInternalServerError),
+}
+
+func NewErrMutatorNotEnabled(a Mutator) *herodot.DefaultError {
+	return ErrMutatorNotEnabled.WithTrace(errors.New("")).WithReasonf(`Response mutator "%s" is disabled per configuration.`, a.GetID())
+}
+
+func NewErrMutatorMisconfigured(a Mutator, err error) *herodot.DefaultError {
+	return ErrMutatorNotEnabled.WithTrace(err).WithReasonf(
+		`Configuration for response mutator "%s" could not be validated: %s`,
+		a.GetID(),
+		err,
+	)
+}
+
+// Mutator transforms the upstream's response before it is returned to the client.
+type Mutator interface {
+	MutateResponse(res *http.Response, session *authn.AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error
+	GetID() string
+	Validate(config json.RawMessage) error
+}
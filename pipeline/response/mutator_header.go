@@ -0,0 +1,68 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/pipeline"
+	"github.com/ory/oathkeeper/pipeline/authn"
+)
+
+type MutatorHeaderConfig struct {
+	Set    map[string]string `json:"set"`
+	Remove []string          `json:"remove"`
+}
+
+// MutatorHeader sets and removes headers of the upstream's response, for example to add security headers or to
+// strip headers which are meant for internal use only.
+type MutatorHeader struct {
+	c configuration.Provider
+}
+
+func NewMutatorHeader(c configuration.Provider) *MutatorHeader {
+	return &MutatorHeader{c: c}
+}
+
+func (a *MutatorHeader) GetID() string {
+	return "header"
+}
+
+func (a *MutatorHeader) MutateResponse(res *http.Response, _ *authn.AuthenticationSession, config json.RawMessage, _ pipeline.Rule) error {
+	cfg, err := a.config(config)
+	if err != nil {
+		return err
+	}
+
+	if res.Header == nil {
+		res.Header = http.Header{}
+	}
+
+	for _, hdr := range cfg.Remove {
+		res.Header.Del(hdr)
+	}
+
+	for hdr, value := range cfg.Set {
+		res.Header.Set(hdr, value)
+	}
+
+	return nil
+}
+
+func (a *MutatorHeader) Validate(config json.RawMessage) error {
+	if !a.c.ResponseMutatorIsEnabled(a.GetID()) {
+		return NewErrMutatorNotEnabled(a)
+	}
+
+	_, err := a.config(config)
+	return err
+}
+
+func (a *MutatorHeader) config(config json.RawMessage) (*MutatorHeaderConfig, error) {
+	var c MutatorHeaderConfig
+	if err := a.c.ResponseMutatorConfig(a.GetID(), config, &c); err != nil {
+		return nil, NewErrMutatorMisconfigured(a, err)
+	}
+
+	return &c, nil
+}
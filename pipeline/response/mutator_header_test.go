@@ -0,0 +1,79 @@
+package response_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/ory/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/rule"
+)
+
+func TestMutatorHeader(t *testing.T) {
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	a, err := reg.PipelineResponseMutator("header")
+	require.NoError(t, err)
+	assert.Equal(t, "header", a.GetID())
+
+	t.Run("method=mutate", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			config json.RawMessage
+			header http.Header
+			expect http.Header
+			err    bool
+		}{
+			"set headers": {
+				config: json.RawMessage(`{"set":{"X-Frame-Options":"DENY"}}`),
+				header: http.Header{"X-Frame-Options": {"SAMEORIGIN"}, "Content-Type": {"text/plain"}},
+				expect: http.Header{"X-Frame-Options": {"DENY"}, "Content-Type": {"text/plain"}},
+			},
+			"remove headers": {
+				config: json.RawMessage(`{"remove":["Server","x-powered-by"]}`),
+				header: http.Header{"Server": {"nginx"}, "X-Powered-By": {"PHP"}, "Content-Type": {"text/plain"}},
+				expect: http.Header{"Content-Type": {"text/plain"}},
+			},
+			"remove before set": {
+				config: json.RawMessage(`{"set":{"Server":"oathkeeper"},"remove":["Server"]}`),
+				header: http.Header{"Server": {"nginx"}},
+				expect: http.Header{"Server": {"oathkeeper"}},
+			},
+			"nil headers": {
+				config: json.RawMessage(`{"set":{"X-Frame-Options":"DENY"}}`),
+				expect: http.Header{"X-Frame-Options": {"DENY"}},
+			},
+			"unknown config field": {
+				config: json.RawMessage(`{"foo":"bar"}`),
+				err:    true,
+			},
+		} {
+			t.Run("case="+name, func(t *testing.T) {
+				res := &http.Response{Header: tc.header}
+				err := a.MutateResponse(res, &authn.AuthenticationSession{Subject: "foo"}, tc.config, &rule.Rule{ID: "test-rule"})
+				if tc.err {
+					require.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expect, res.Header)
+			})
+		}
+	})
+
+	t.Run("method=validate", func(t *testing.T) {
+		viper.Set(configuration.ViperKeyResponseMutatorHeaderIsEnabled, true)
+		require.NoError(t, a.Validate(json.RawMessage(`{"set":{}}`)))
+
+		viper.Reset()
+		viper.Set(configuration.ViperKeyResponseMutatorHeaderIsEnabled, false)
+		require.Error(t, a.Validate(json.RawMessage(`{"set":{}}`)))
+	})
+}
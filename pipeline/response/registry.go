@@ -0,0 +1,6 @@
+package response
+
+type Registry interface {
+	AvailablePipelineResponseMutators() []string
+	PipelineResponseMutator(string) (Mutator, error)
+}
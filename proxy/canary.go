@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/ory/oathkeeper/rule"
+)
+
+// Upstream cohorts a request can be routed to.
+const (
+	UpstreamCohortStable = "stable"
+	UpstreamCohortCanary = "canary"
+)
+
+// routeUpstream decides whether the request is routed to the canary upstream of the rule. It returns the cohort and
+// the rule with the URL of the chosen upstream.
+func routeUpstream(r *http.Request, rl *rule.Rule) (string, *rule.Rule) {
+	c := rl.Upstream.Canary
+	if c == nil {
+		return UpstreamCohortStable, rl
+	}
+
+	canary := rand.Float64()*100 < c.Weight
+	if c.Header != "" {
+		switch strings.ToLower(r.Header.Get(c.Header)) {
+		case "always":
+			canary = true
+		case "never":
+			canary = false
+		}
+	}
+
+	if !canary {
+		return UpstreamCohortStable, rl
+	}
+
+	routed := *rl
+	routed.Upstream.URL = c.URL
+	return UpstreamCohortCanary, &routed
+}
@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/oathkeeper/rule"
+)
+
+func TestRouteUpstream(t *testing.T) {
+	for k, tc := range []struct {
+		canary       *rule.UpstreamCanary
+		header       string
+		expectCohort string
+		expectURL    string
+	}{
+		{expectCohort: UpstreamCohortStable, expectURL: "http://stable"},
+		{canary: &rule.UpstreamCanary{URL: "http://canary", Weight: 0}, expectCohort: UpstreamCohortStable, expectURL: "http://stable"},
+		{canary: &rule.UpstreamCanary{URL: "http://canary", Weight: 100}, expectCohort: UpstreamCohortCanary, expectURL: "http://canary"},
+		{canary: &rule.UpstreamCanary{URL: "http://canary", Weight: 0, Header: "X-Canary"}, header: "always", expectCohort: UpstreamCohortCanary, expectURL: "http://canary"},
+		{canary: &rule.UpstreamCanary{URL: "http://canary", Weight: 100, Header: "X-Canary"}, header: "never", expectCohort: UpstreamCohortStable, expectURL: "http://stable"},
+		{canary: &rule.UpstreamCanary{URL: "http://canary", Weight: 100, Header: "X-Canary"}, header: "maybe", expectCohort: UpstreamCohortCanary, expectURL: "http://canary"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://oathkeeper/", nil)
+			if tc.header != "" {
+				r.Header.Set("X-Canary", tc.header)
+			}
+
+			rl := &rule.Rule{Upstream: rule.Upstream{URL: "http://stable", Canary: tc.canary}}
+			cohort, routed := routeUpstream(r, rl)
+			assert.Equal(t, tc.expectCohort, cohort)
+			assert.Equal(t, tc.expectURL, routed.Upstream.URL)
+			assert.Equal(t, "http://stable", rl.Upstream.URL, "the rule must not be modified")
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// IsGRPCRequest returns true if the request is a gRPC call. gRPC calls are sent over HTTP/2 with a content type of
+// "application/grpc", optionally followed by a suffix such as "+proto".
+func IsGRPCRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// GRPCAware serves gRPC calls with grpc and all other requests with rest. gRPC streams require that every message is
+// flushed to the client immediately, which is why gRPC calls are usually served by a reverse proxy with a negative
+// flush interval.
+func GRPCAware(rest, grpc http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsGRPCRequest(r) {
+			grpc.ServeHTTP(w, r)
+			return
+		}
+		rest.ServeHTTP(w, r)
+	})
+}
+
+// H2C serves cleartext HTTP/2 (h2c) connections in addition to HTTP/1.x ones, which allows gRPC clients to connect
+// to a proxy that is not served over TLS.
+func H2C(h http.Handler) http.Handler {
+	return h2c.NewHandler(h, &http2.Server{})
+}
+
+// h2cTransport forwards requests to upstreams with the "h2c" URL scheme over cleartext HTTP/2.
+type h2cTransport struct {
+	t *http2.Transport
+}
+
+func newH2CTransport() *h2cTransport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return &h2cTransport{t: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dialer.Dial(network, addr)
+		},
+	}}
+}
+
+func (t *h2cTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	u := *r.URL
+	u.Scheme = "http"
+
+	out := new(http.Request)
+	*out = *r
+	out.URL = &u
+	return t.t.RoundTrip(out)
+}
@@ -0,0 +1,118 @@
+package proxy_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/ory/viper"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/proxy"
+	"github.com/ory/oathkeeper/rule"
+)
+
+func TestGRPCAware(t *testing.T) {
+	h := proxy.GRPCAware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("rest")) }),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("grpc")) }),
+	)
+
+	for k, tc := range []struct {
+		protoMajor  int
+		contentType string
+		expect      string
+	}{
+		{protoMajor: 2, contentType: "application/grpc", expect: "grpc"},
+		{protoMajor: 2, contentType: "application/grpc+proto", expect: "grpc"},
+		{protoMajor: 2, contentType: "application/json", expect: "rest"},
+		{protoMajor: 1, contentType: "application/grpc", expect: "rest"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/helloworld.Greeter/SayHello", nil)
+			r.ProtoMajor = tc.protoMajor
+			r.Header.Set("Content-Type", tc.contentType)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			assert.Equal(t, tc.expect, w.Body.String())
+		})
+	}
+}
+
+func TestGRPCOverH2C(t *testing.T) {
+	// The upstream behaves like a gRPC server without TLS: it only speaks cleartext HTTP/2 and sends the status in
+	// the trailers.
+	upstream := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			w.WriteHeader(http.StatusHTTPVersionNotSupported)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write(body)
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer upstream.Close()
+
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	p := reg.Proxy()
+	ts := httptest.NewServer(proxy.H2C(proxy.GRPCAware(
+		&httputil.ReverseProxy{Director: p.Director, Transport: p},
+		&httputil.ReverseProxy{Director: p.Director, Transport: p, FlushInterval: -1},
+	)))
+	defer ts.Close()
+
+	viper.Set(configuration.ViperKeyAuthenticatorNoopIsEnabled, true)
+	viper.Set(configuration.ViperKeyAuthorizerAllowIsEnabled, true)
+	viper.Set(configuration.ViperKeyMutatorNoopIsEnabled, true)
+
+	reg.RuleRepository().(*rule.RepositoryMemory).WithRules([]rule.Rule{{
+		ID:             "greeter",
+		Match:          &rule.Match{Methods: []string{"POST"}, URL: ts.URL + "/helloworld.Greeter/<.*>"},
+		Authenticators: []rule.Handler{{Handler: "noop"}},
+		Authorizer:     rule.Handler{Handler: "allow"},
+		Mutators:       []rule.Handler{{Handler: "noop"}},
+		Upstream:       rule.Upstream{URL: "h2c://" + upstream.Listener.Addr().String()},
+	}})
+	require.NoError(t, reg.RuleRepository().SetMatchingStrategy(context.Background(), configuration.Regexp))
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+
+	req, err := http.NewRequest("POST", ts.URL+"/helloworld.Greeter/SayHello", bytes.NewBufferString("hello"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc")
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode, "%s", body)
+	assert.Equal(t, 2, res.ProtoMajor)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, "0", res.Trailer.Get("Grpc-Status"))
+}
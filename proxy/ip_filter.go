@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/rule"
+	"github.com/ory/oathkeeper/x"
+)
+
+// filterRemoteIP returns an ErrForbidden if the IP address of the client is denied by the global IP filter or the
+// IP filter of the rule.
+func (d *RequestHandler) filterRemoteIP(r *http.Request, rl *rule.Rule) error {
+	c, err := d.c.AccessRuleIPFilter()
+	if err != nil {
+		return err
+	}
+
+	if len(c.Allow) == 0 && len(c.Deny) == 0 && rl.IPFilter == nil {
+		return nil
+	}
+
+	ip, err := x.ClientIP(r, c.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	if err := filterIP(ip, c.Allow, c.Deny); err != nil {
+		return err
+	}
+
+	if rl.IPFilter == nil {
+		return nil
+	}
+
+	allow, deny, err := rl.IPFilter.Networks()
+	if err != nil {
+		return err
+	}
+	return filterIP(ip, allow, deny)
+}
+
+func filterIP(ip net.IP, allow, deny []*net.IPNet) error {
+	if x.ContainsIP(deny, ip) {
+		return errors.WithStack(helper.ErrForbidden.WithReason("Requests from this IP address are not allowed.").WithDebugf("IP address %s is denied.", ip))
+	}
+
+	if len(allow) > 0 && !x.ContainsIP(allow, ip) {
+		return errors.WithStack(helper.ErrForbidden.WithReason("Requests from this IP address are not allowed.").WithDebugf("IP address %s is not allowed.", ip))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/rule"
+)
+
+// ErrMaintenanceMode is returned if a request was rejected because of the maintenance mode.
+var ErrMaintenanceMode = errors.New("the request was rejected because the maintenance mode is enabled")
+
+// MaintenanceMode rejects all requests or requests matching rules with one of the given tags.
+//
+// swagger:model maintenanceMode
+type MaintenanceMode struct {
+	// Enabled enables the maintenance mode.
+	Enabled bool `json:"enabled"`
+
+	// Tags restricts the maintenance mode to requests matching access rules with one of these tags. If empty, all
+	// requests are rejected, including requests which do not match any access rule.
+	Tags []string `json:"tags,omitempty"`
+
+	// StatusCode is the status code (200 - 599) of the response to rejected requests. Defaults to 503.
+	StatusCode int `json:"status_code,omitempty"`
+
+	// Body is the body of the response to rejected requests. Defaults to a JSON error.
+	Body string `json:"body,omitempty"`
+
+	// ContentType is the content type of Body. Defaults to "text/plain; charset=utf-8" if Body is set.
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// Maintenance holds the maintenance mode which can be changed at runtime.
+type Maintenance struct {
+	sync.RWMutex
+	mode MaintenanceMode
+}
+
+func NewMaintenance() *Maintenance {
+	return &Maintenance{}
+}
+
+// Mode returns the current maintenance mode.
+func (m *Maintenance) Mode() MaintenanceMode {
+	m.RLock()
+	defer m.RUnlock()
+	return m.mode
+}
+
+// SetMode validates and applies the maintenance mode.
+func (m *Maintenance) SetMode(mode MaintenanceMode) error {
+	if mode.StatusCode == 0 {
+		mode.StatusCode = http.StatusServiceUnavailable
+	} else if mode.StatusCode < 200 || mode.StatusCode > 599 {
+		// Informational responses are not final, clients would keep waiting for the actual response.
+		return errors.Errorf("status code %d is invalid", mode.StatusCode)
+	}
+
+	if mode.Body != "" && mode.ContentType == "" {
+		mode.ContentType = "text/plain; charset=utf-8"
+	}
+
+	m.Lock()
+	m.mode = mode
+	m.Unlock()
+	return nil
+}
+
+// Rejects returns true if requests matching the rule must be rejected. The rule is nil if the request did not match
+// any rule.
+func (m *Maintenance) Rejects(rl *rule.Rule) bool {
+	m.RLock()
+	defer m.RUnlock()
+
+	if !m.mode.Enabled {
+		return false
+	}
+
+	if len(m.mode.Tags) == 0 {
+		return true
+	}
+
+	if rl == nil {
+		return false
+	}
+
+	for _, tag := range m.mode.Tags {
+		for _, t := range rl.Tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Write writes the response to a rejected request.
+func (m *Maintenance) Write(w http.ResponseWriter) {
+	mode := m.Mode()
+	code := mode.StatusCode
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+
+	if mode.Body != "" {
+		w.Header().Set("Content-Type", mode.ContentType)
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(mode.Body))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"status":  http.StatusText(code),
+			"message": "The service is down for maintenance",
+		},
+	})
+}
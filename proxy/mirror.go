@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/ory/oathkeeper/rule"
+)
+
+const (
+	// mirrorTimeout limits the duration of a request to a shadow upstream.
+	mirrorTimeout = 10 * time.Second
+
+	// maxMirrorBodySize is the maximum size of a request body which is buffered to mirror the request.
+	maxMirrorBodySize = 1 << 20
+
+	// maxConcurrentMirrors limits the number of requests to shadow upstreams in flight. Further requests are not
+	// mirrored so that a slow shadow upstream can not exhaust the resources of the proxy.
+	maxConcurrentMirrors = 100
+)
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// mirror sends a copy of the request to the shadow upstream of the rule, if the request was chosen to be mirrored.
+// It must be called after the request passed the pipeline and before the URL is rewritten to the upstream.
+// The response of the shadow upstream is discarded.
+func (d *Proxy) mirror(r *http.Request, rl *rule.Rule) {
+	m := rl.Upstream.Mirror
+	if m == nil || m.Percentage <= 0 || r.Header.Get("Upgrade") != "" || rand.Float64()*100 >= m.Percentage {
+		return
+	}
+
+	l := d.r.Logger().
+		WithField("http_method", r.Method).
+		WithField("http_url", r.URL.String()).
+		WithField("rule_id", rl.ID).
+		WithField("mirror_url", m.URL)
+
+	select {
+	case d.mirrors <- struct{}{}:
+	default:
+		l.Info("Not mirroring request because too many mirrored requests are in flight")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	release := func() {
+		cancel()
+		<-d.mirrors
+	}
+	mr := r.Clone(ctx)
+	mr.RequestURI = ""
+
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength < 0 || r.ContentLength > maxMirrorBodySize {
+			release()
+			l.Debug("Not mirroring request because its body is too large or of unknown size")
+			return
+		}
+
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxMirrorBodySize))
+		if err != nil {
+			release()
+			// Forward what was read followed by the failing body so that the request to the upstream fails as well.
+			r.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+			l.WithError(err).Debug("Not mirroring request because its body could not be read")
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		mr.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	shadow := *rl
+	shadow.Upstream.URL = m.URL
+	if err := ConfigureBackendURL(mr, &shadow); err != nil {
+		release()
+		l.WithError(err).Warn("Unable to mirror request")
+		return
+	}
+
+	go func() {
+		defer release()
+
+		res, err := d.upstream.RoundTrip(mr)
+		if err != nil {
+			l.WithError(err).Info("Mirrored request failed")
+			return
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+
+		l.WithField("status_code", res.StatusCode).Debug("Mirrored request")
+	}()
+}
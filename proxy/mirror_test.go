@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/logrusx"
+
+	"github.com/ory/oathkeeper/rule"
+)
+
+type mirrorTestRegistry struct {
+	proxyRegistry
+}
+
+func (r *mirrorTestRegistry) Logger() *logrusx.Logger {
+	return logrusx.New("", "")
+}
+
+func TestMirror(t *testing.T) {
+	mirrored := make(chan string, 10)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mirrored <- r.Method + " " + r.URL.Path + " " + string(body)
+	}))
+	defer shadow.Close()
+
+	d := &Proxy{r: new(mirrorTestRegistry), upstream: http.DefaultTransport, mirrors: make(chan struct{}, maxConcurrentMirrors)}
+
+	for k, tc := range []struct {
+		method     string
+		body       string
+		percentage float64
+		expect     string
+	}{
+		{method: "GET", percentage: 100, expect: "GET /shadow/users/1 "},
+		{method: "POST", body: "payload", percentage: 100, expect: "POST /shadow/users/1 payload"},
+		{method: "GET", percentage: 0},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, "http://oathkeeper/users/1", strings.NewReader(tc.body))
+			if tc.body == "" {
+				r.Body = http.NoBody
+			}
+			EnrichRequestedURL(r)
+
+			d.mirror(r, &rule.Rule{
+				ID: "rule",
+				Upstream: rule.Upstream{
+					URL:    "http://upstream",
+					Mirror: &rule.UpstreamMirror{URL: shadow.URL + "/shadow", Percentage: tc.percentage},
+				},
+			})
+
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.body, string(body), "the body must still be forwarded to the upstream")
+			assert.Equal(t, "/users/1", r.URL.Path, "the request to the upstream must not be modified")
+
+			if tc.expect == "" {
+				select {
+				case m := <-mirrored:
+					t.Fatalf("request was mirrored unexpectedly: %s", m)
+				case <-time.After(100 * time.Millisecond):
+				}
+				return
+			}
+
+			select {
+			case m := <-mirrored:
+				assert.Equal(t, tc.expect, m)
+			case <-time.After(time.Second):
+				t.Fatal("request was not mirrored")
+			}
+		})
+	}
+}
+
+func TestMirrorDropsRequestsIfTooManyAreInFlight(t *testing.T) {
+	mirrored := make(chan string, 10)
+	block := make(chan struct{})
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrored <- r.URL.Path
+		if r.URL.Path == "/shadow/slow" {
+			<-block
+		}
+	}))
+	defer shadow.Close()
+
+	d := &Proxy{r: new(mirrorTestRegistry), upstream: http.DefaultTransport, mirrors: make(chan struct{}, 1)}
+	mirror := func(path string) {
+		r := httptest.NewRequest("GET", "http://oathkeeper"+path, nil)
+		EnrichRequestedURL(r)
+		d.mirror(r, &rule.Rule{
+			ID: "rule",
+			Upstream: rule.Upstream{
+				URL:    "http://upstream",
+				Mirror: &rule.UpstreamMirror{URL: shadow.URL + "/shadow", Percentage: 100},
+			},
+		})
+	}
+	expect := func(path string) {
+		select {
+		case m := <-mirrored:
+			assert.Equal(t, path, m)
+		case <-time.After(time.Second):
+			t.Fatalf("request %s was not mirrored", path)
+		}
+	}
+
+	mirror("/slow")
+	expect("/shadow/slow")
+
+	mirror("/dropped")
+	select {
+	case m := <-mirrored:
+		t.Fatalf("request was mirrored although the limit was reached: %s", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(block)
+	require.Eventually(t, func() bool { return len(d.mirrors) == 0 }, time.Second, 10*time.Millisecond)
+
+	mirror("/fast")
+	expect("/shadow/fast")
+}
@@ -22,12 +22,17 @@ package proxy
 
 import (
 	"context"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/metrics"
 	"github.com/ory/oathkeeper/pipeline/authn"
+	"github.com/ory/oathkeeper/pipeline/mutate"
 	"github.com/ory/oathkeeper/x"
 
 	"github.com/pkg/errors"
@@ -41,14 +46,31 @@ type proxyRegistry interface {
 
 	ProxyRequestHandler() *RequestHandler
 	RuleMatcher() rule.Matcher
+	Maintenance() *Maintenance
 }
 
-func NewProxy(r proxyRegistry) *Proxy {
-	return &Proxy{r: r}
+func NewProxy(r proxyRegistry, c configuration.Provider) *Proxy {
+	t, err := NewUpstreamTransport(c.ProxyUpstreamTransport())
+	if err != nil {
+		// Falling back to the default transport would silently trust the system CAs instead of the configured ones.
+		r.Logger().WithError(err).Fatal("Unable to configure the upstream transport.")
+	}
+
+	return &Proxy{
+		r:         r,
+		upstream:  t,
+		transport: &retryTransport{r: r, c: c, next: t},
+		mirrors:   make(chan struct{}, maxConcurrentMirrors),
+	}
 }
 
 type Proxy struct {
-	r proxyRegistry
+	r         proxyRegistry
+	upstream  http.RoundTripper
+	transport http.RoundTripper
+
+	// mirrors holds a value for each mirrored request in flight.
+	mirrors chan struct{}
 }
 
 type key int
@@ -57,6 +79,7 @@ const (
 	director key = iota + 1
 	ContextKeyMatchedRule
 	ContextKeySession
+	ContextKeyUpstreamCohort
 )
 
 func (d *Proxy) RoundTrip(r *http.Request) (*http.Response, error) {
@@ -68,19 +91,29 @@ func (d *Proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 		"http_user_agent": r.UserAgent(),
 	}
 
-	if sess, ok := r.Context().Value(ContextKeySession).(*authn.AuthenticationSession); ok {
+	sess, ok := r.Context().Value(ContextKeySession).(*authn.AuthenticationSession)
+	if ok {
 		fields["subject"] = sess.Subject
 	}
 
 	rl, _ := r.Context().Value(ContextKeyMatchedRule).(*rule.Rule)
 
+	if cohort, ok := r.Context().Value(ContextKeyUpstreamCohort).(string); ok {
+		fields["upstream_cohort"] = cohort
+	}
+
 	if err, ok := r.Context().Value(director).(error); ok && err != nil {
 		d.r.Logger().WithError(err).
 			WithFields(fields).
 			WithField("granted", false).
 			Warn("Access request denied")
 
-		d.r.ProxyRequestHandler().HandleError(rw, r, rl, err)
+		if errors.Cause(err) == ErrMaintenanceMode {
+			d.r.Maintenance().Write(rw)
+		} else {
+			d.r.ProxyRequestHandler().HandleError(rw, r, rl, err)
+		}
+		setCorrelationID(r, rw.header)
 
 		return &http.Response{
 			StatusCode: rw.code,
@@ -88,7 +121,16 @@ func (d *Proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 			Header:     rw.header,
 		}, nil
 	} else if err == nil {
-		res, err := http.DefaultTransport.RoundTrip(r)
+		start := time.Now()
+		res, err := d.transport.RoundTrip(r)
+		if rl != nil {
+			status := http.StatusBadGateway
+			if err == nil {
+				status = res.StatusCode
+			}
+			metrics.ObserveUpstream(rl.ID, r.Method, status, time.Since(start))
+		}
+
 		if err != nil {
 			d.r.Logger().
 				WithError(errors.WithStack(err)).
@@ -96,11 +138,36 @@ func (d *Proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 				WithFields(fields).
 				Warn("Access request denied because roundtrip failed")
 			// don't need to return because covered in next line
+		} else if err := d.r.ProxyRequestHandler().HandleResponse(r, res, rl, sess); err != nil {
+			res.Body.Close()
+			d.r.Logger().
+				WithError(err).
+				WithField("granted", false).
+				WithFields(fields).
+				Warn("Access request denied because the upstream response could not be mutated")
+
+			d.r.ProxyRequestHandler().HandleError(rw, r, rl, err)
+			setCorrelationID(r, rw.header)
+
+			return &http.Response{
+				StatusCode: rw.code,
+				Body:       ioutil.NopCloser(rw.buffer),
+				Header:     rw.header,
+			}, nil
 		} else {
 			d.r.Logger().
 				WithField("granted", true).
 				WithFields(fields).
 				Warn("Access request granted")
+
+			setCorrelationID(r, res.Header)
+
+			if rwc, ok := res.Body.(io.ReadWriteCloser); ok && IsWebSocketUpgrade(res) {
+				if rl != nil {
+					fields["rule_id"] = rl.ID
+				}
+				res.Body = newWebSocketConn(rwc, d.r.Logger(), fields)
+			}
 		}
 
 		return res, err
@@ -114,6 +181,7 @@ func (d *Proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 		Warn("Unable to type assert context")
 
 	d.r.ProxyRequestHandler().HandleError(rw, r, rl, err)
+	setCorrelationID(r, rw.header)
 
 	return &http.Response{
 		StatusCode: rw.code,
@@ -125,12 +193,16 @@ func (d *Proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 func (d *Proxy) Director(r *http.Request) {
 	EnrichRequestedURL(r)
 	rl, err := d.r.RuleMatcher().Match(r.Context(), r.Method, r.URL)
-	if err != nil {
+	if d.r.Maintenance().Rejects(rl) {
+		*r = *r.WithContext(context.WithValue(r.Context(), director, errors.WithStack(ErrMaintenanceMode)))
+		return
+	} else if err != nil {
 		*r = *r.WithContext(context.WithValue(r.Context(), director, err))
 		return
 	}
 
 	*r = *r.WithContext(context.WithValue(r.Context(), ContextKeyMatchedRule, rl))
+	metrics.SetMatchedRule(r.Context(), rl.ID)
 	s, err := d.r.ProxyRequestHandler().HandleRequest(r, rl)
 	if err != nil {
 		*r = *r.WithContext(context.WithValue(r.Context(), director, err))
@@ -142,7 +214,14 @@ func (d *Proxy) Director(r *http.Request) {
 		r.Header.Set(h, s.Header.Get(h))
 	}
 
-	if err := ConfigureBackendURL(r, rl); err != nil {
+	d.mirror(r, rl)
+
+	cohort, upstream := routeUpstream(r, rl)
+	if rl.Upstream.Canary != nil {
+		*r = *r.WithContext(context.WithValue(r.Context(), ContextKeyUpstreamCohort, cohort))
+	}
+
+	if err := ConfigureBackendURL(r, upstream); err != nil {
 		*r = *r.WithContext(context.WithValue(r.Context(), director, err))
 		return
 	}
@@ -151,6 +230,13 @@ func (d *Proxy) Director(r *http.Request) {
 	*r = *r.WithContext(context.WithValue(r.Context(), director, en))
 }
 
+// setCorrelationID returns the correlation ID set by the correlation_id mutator to the client.
+func setCorrelationID(r *http.Request, header http.Header) {
+	if c, ok := mutate.CorrelationIDFromContext(r.Context()); ok && header != nil {
+		header.Set(c.Header, c.ID)
+	}
+}
+
 // EnrichRequestedURL sets Scheme and Host values in a URL passed down by a http server. Per default, the URL
 // does not contain host nor scheme values.
 func EnrichRequestedURL(r *http.Request) {
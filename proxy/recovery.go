@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/ory/herodot"
+	"github.com/pkg/errors"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/oathkeeper/x"
+)
+
+type recoveryRegistry interface {
+	x.RegistryWriter
+	x.RegistryLogger
+}
+
+// Recovery is a middleware which recovers from panics in the handlers following it, for example in a pipeline
+// handler, logs the stack trace and responds with a 500 Internal Server Error.
+type Recovery struct {
+	r recoveryRegistry
+}
+
+func NewRecovery(r recoveryRegistry) *Recovery {
+	return &Recovery{r: r}
+}
+
+func (m *Recovery) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		if v == http.ErrAbortHandler {
+			// Used by the reverse proxy to abort the response if copying the upstream's response fails.
+			panic(v)
+		}
+
+		m.r.Logger().
+			WithField("http_method", r.Method).
+			WithField("http_url", r.URL.String()).
+			WithField("http_host", r.Host).
+			WithField("panic", fmt.Sprintf("%v", v)).
+			WithField("stack_trace", string(debug.Stack())).
+			Error("Recovered from a panic while handling the request")
+
+		if rw, ok := w.(negroni.ResponseWriter); ok && rw.Written() {
+			// The response was already started, the best we can do is to stop sending it.
+			panic(http.ErrAbortHandler)
+		}
+
+		m.r.Writer().WriteError(w, r, errors.WithStack(herodot.ErrInternalServerError.WithReason("The request could not be handled because of an unexpected error.")))
+	}()
+
+	next(w, r)
+}
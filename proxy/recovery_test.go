@@ -0,0 +1,50 @@
+package proxy_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/negroni"
+
+	"github.com/ory/oathkeeper/internal"
+	"github.com/ory/oathkeeper/proxy"
+)
+
+func TestRecovery(t *testing.T) {
+	reg := internal.NewRegistry(internal.NewConfigurationWithDefaults())
+
+	n := negroni.New(proxy.NewRecovery(reg))
+	n.UseHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			panic("handler bug")
+		case "/abort":
+			panic(http.ErrAbortHandler)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	t.Run("case=passes through requests", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		n.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("case=converts panics into internal server errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NotPanics(t, func() {
+			n.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+		})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "unexpected error")
+	})
+
+	t.Run("case=does not recover from aborted responses", func(t *testing.T) {
+		assert.Panics(t, func() {
+			n.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+		})
+	})
+}
@@ -23,6 +23,7 @@ package proxy
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/ory/herodot"
 	"github.com/ory/x/errorsx"
@@ -34,10 +35,12 @@ import (
 	"github.com/ory/oathkeeper/pipeline/authz"
 	pe "github.com/ory/oathkeeper/pipeline/errors"
 	"github.com/ory/oathkeeper/pipeline/mutate"
+	"github.com/ory/oathkeeper/pipeline/response"
 
 	"github.com/pkg/errors"
 
 	"github.com/ory/oathkeeper/helper"
+	"github.com/ory/oathkeeper/metrics"
 	"github.com/ory/oathkeeper/rule"
 )
 
@@ -48,6 +51,7 @@ type requestHandlerRegistry interface {
 	authn.Registry
 	authz.Registry
 	mutate.Registry
+	response.Registry
 	pe.Registry
 }
 
@@ -60,6 +64,12 @@ type whenConfig struct {
 	When pe.Whens `json:"when"`
 }
 
+// headerCarrier is implemented by errors which require additional headers in the error response, for example
+// "Retry-After".
+type headerCarrier interface {
+	Header() http.Header
+}
+
 func NewRequestHandler(r requestHandlerRegistry, c configuration.Provider) *RequestHandler {
 	return &RequestHandler{r: r, c: c}
 }
@@ -89,6 +99,12 @@ func (d *RequestHandler) HandleError(w http.ResponseWriter, r *http.Request, rl
 		rl = new(rule.Rule)
 	}
 
+	if hc, ok := errorsx.Cause(handleErr).(headerCarrier); ok {
+		for k, v := range hc.Header() {
+			w.Header()[k] = v
+		}
+	}
+
 	var h pe.Handler
 	var config json.RawMessage
 	for _, re := range rl.Errors {
@@ -178,8 +194,19 @@ func (d *RequestHandler) HandleRequest(r *http.Request, rl *rule.Rule) (session
 		"rule_id":         rl.ID,
 	}
 
+	if err := d.filterRemoteIP(r, rl); err != nil {
+		d.r.Logger().WithError(err).
+			WithFields(fields).
+			WithField("granted", false).
+			WithField("http_remote_addr", r.RemoteAddr).
+			WithField("reason_id", "ip_filter_denied").
+			Warn("The IP address of the client is not allowed")
+		return nil, err
+	}
+
 	// initialize the session used during all the flow
 	session = d.InitializeAuthnSession(r, rl)
+	deadline := d.pipelineDeadline()
 
 	if len(rl.Authenticators) == 0 {
 		err = errors.New("No authentication handler was set in the rule")
@@ -213,7 +240,11 @@ func (d *RequestHandler) HandleRequest(r *http.Request, rl *rule.Rule) (session
 			return nil, err
 		}
 
-		err = anh.Authenticate(r, session, a.Config, rl)
+		start := time.Now()
+		err = runHandler(r, deadline, d.c.AuthenticatorTimeout(), metrics.HandlerTypeAuthenticator, a.Handler, func() error {
+			return anh.Authenticate(r, session, a.Config, rl)
+		})
+		observePipelineHandler(rl, metrics.HandlerTypeAuthenticator, a.Handler, start, err)
 		if err != nil {
 			switch errors.Cause(err).Error() {
 			case authn.ErrAuthenticatorNotResponsible.Error():
@@ -271,7 +302,12 @@ func (d *RequestHandler) HandleRequest(r *http.Request, rl *rule.Rule) (session
 		return nil, err
 	}
 
-	if err := azh.Authorize(r, session, rl.Authorizer.Config, rl); err != nil {
+	start := time.Now()
+	err = runHandler(r, deadline, d.c.AuthorizerTimeout(), metrics.HandlerTypeAuthorizer, rl.Authorizer.Handler, func() error {
+		return azh.Authorize(r, session, rl.Authorizer.Config, rl)
+	})
+	observePipelineHandler(rl, metrics.HandlerTypeAuthorizer, rl.Authorizer.Handler, start, err)
+	if err != nil {
 		d.r.Logger().
 			WithError(err).
 			WithFields(fields).
@@ -315,7 +351,12 @@ func (d *RequestHandler) HandleRequest(r *http.Request, rl *rule.Rule) (session
 			return nil, err
 		}
 
-		if err := sh.Mutate(r, session, m.Config, rl); err != nil {
+		start := time.Now()
+		err = runHandler(r, deadline, d.c.MutatorTimeout(), metrics.HandlerTypeMutator, m.Handler, func() error {
+			return sh.Mutate(r, session, m.Config, rl)
+		})
+		observePipelineHandler(rl, metrics.HandlerTypeMutator, m.Handler, start, err)
+		if err != nil {
 			d.r.Logger().WithError(err).
 				WithFields(fields).
 				WithField("granted", false).
@@ -329,6 +370,65 @@ func (d *RequestHandler) HandleRequest(r *http.Request, rl *rule.Rule) (session
 	return session, nil
 }
 
+// HandleResponse applies the rule's response mutators to the upstream's response.
+func (d *RequestHandler) HandleResponse(r *http.Request, res *http.Response, rl *rule.Rule, session *authn.AuthenticationSession) error {
+	fields := map[string]interface{}{
+		"http_method":     r.Method,
+		"http_url":        r.URL.String(),
+		"http_host":       r.Host,
+		"http_user_agent": r.UserAgent(),
+		"rule_id":         rl.ID,
+	}
+
+	for _, m := range rl.ResponseMutators {
+		rm, err := d.r.PipelineResponseMutator(m.Handler)
+		if err != nil {
+			d.r.Logger().WithError(err).
+				WithFields(fields).
+				WithField("response_mutation_handler", m.Handler).
+				WithField("reason_id", "unknown_response_mutation_handler").
+				Warn("Unknown response mutator requested")
+			return err
+		}
+
+		if err := rm.Validate(m.Config); err != nil {
+			d.r.Logger().WithError(err).
+				WithFields(fields).
+				WithField("response_mutation_handler", m.Handler).
+				WithField("reason_id", "invalid_response_mutation_handler").
+				Warn("Invalid response mutator requested")
+			return err
+		}
+
+		start := time.Now()
+		err = rm.MutateResponse(res, session, m.Config, rl)
+		observePipelineHandler(rl, metrics.HandlerTypeResponseMutator, m.Handler, start, err)
+		if err != nil {
+			d.r.Logger().WithError(err).
+				WithFields(fields).
+				WithField("response_mutation_handler", m.Handler).
+				WithField("reason_id", "response_mutation_handler_error").
+				Warn("The response mutation handler encountered an error")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// observePipelineHandler tracks the duration and result of a pipeline handler.
+func observePipelineHandler(rl *rule.Rule, handlerType, handler string, start time.Time, err error) {
+	result := metrics.HandlerResultSuccess
+	if err != nil {
+		result = metrics.HandlerResultError
+		if errors.Cause(err).Error() == authn.ErrAuthenticatorNotResponsible.Error() {
+			result = metrics.HandlerResultNotResponsible
+		}
+	}
+
+	metrics.ObservePipelineHandler(rl.ID, handlerType, handler, result, time.Since(start))
+}
+
 // InitializeAuthnSession reates an authentication session and initializes it with a Match context if possible
 func (d *RequestHandler) InitializeAuthnSession(r *http.Request, rl *rule.Rule) *authn.AuthenticationSession {
 
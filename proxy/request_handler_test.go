@@ -27,7 +27,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ory/herodot"
@@ -460,6 +462,220 @@ func TestRequestHandler(t *testing.T) {
 	}
 }
 
+func TestRequestHandlerTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	r := rule.Rule{
+		Authenticators: []rule.Handler{{Handler: "anonymous"}},
+		Authorizer:     rule.Handler{Handler: "remote_json", Config: json.RawMessage(`{"remote":"` + ts.URL + `","payload":"{}"}`)},
+		Mutators:       []rule.Handler{{Handler: "noop"}},
+	}
+
+	for k, tc := range []struct {
+		d      string
+		setup  func()
+		expect int
+	}{
+		{
+			d: "should time out because of the authorizer timeout",
+			setup: func() {
+				viper.Set(configuration.ViperKeyAccessRuleTimeoutAuthorizer, "50ms")
+			},
+			expect: http.StatusGatewayTimeout,
+		},
+		{
+			d: "should time out because of the pipeline timeout",
+			setup: func() {
+				viper.Set(configuration.ViperKeyAccessRuleTimeoutAuthenticator, "1s")
+				viper.Set(configuration.ViperKeyAccessRuleTimeoutPipeline, "50ms")
+			},
+			expect: http.StatusGatewayTimeout,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+			conf := internal.NewConfigurationWithDefaults()
+			reg := internal.NewRegistry(conf)
+
+			viper.Set(configuration.ViperKeyAuthenticatorAnonymousIsEnabled, true)
+			viper.Set(configuration.ViperKeyAuthorizerRemoteJSONIsEnabled, true)
+			viper.Set(configuration.ViperKeyMutatorNoopIsEnabled, true)
+			tc.setup()
+
+			req := newTestRequest("http://localhost")
+			_, err := reg.ProxyRequestHandler().HandleRequest(req, &r)
+			require.Error(t, err)
+
+			herr, ok := errors.Cause(err).(*herodot.DefaultError)
+			require.True(t, ok, "%+v", err)
+			assert.Equal(t, tc.expect, herr.StatusCode())
+
+			_, hasDeadline := req.Context().Deadline()
+			assert.False(t, hasDeadline)
+		})
+	}
+}
+
+func TestRequestHandlerTimeoutCancelsOutgoingRequests(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The connection is only watched for a disconnect once the request body was consumed.
+		_ = r.ParseForm()
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+			cancelled <- struct{}{}
+		}
+	}))
+	defer ts.Close()
+
+	conf := internal.NewConfigurationWithDefaults()
+	reg := internal.NewRegistry(conf)
+
+	viper.Set(configuration.ViperKeyAuthenticatorNoopIsEnabled, true)
+	viper.Set(configuration.ViperKeyAuthorizerAllowIsEnabled, true)
+	viper.Set(configuration.ViperKeyMutatorTokenExchangeIsEnabled, true)
+	viper.Set(configuration.ViperKeyAccessRuleTimeoutMutator, "50ms")
+
+	r := rule.Rule{
+		Authenticators: []rule.Handler{{Handler: "noop"}},
+		Authorizer:     rule.Handler{Handler: "allow"},
+		Mutators: []rule.Handler{{Handler: "token_exchange", Config: json.RawMessage(
+			`{"token_url":"` + ts.URL + `","client_id":"client","client_secret":"secret"}`,
+		)}},
+	}
+
+	req := newTestRequest("http://localhost")
+	req.Header = http.Header{"Authorization": {"Bearer end-user-token"}}
+
+	start := time.Now()
+	_, err := reg.ProxyRequestHandler().HandleRequest(req, &r)
+	require.Error(t, err)
+	// The token_exchange client gives up on its own after 500ms.
+	assert.True(t, time.Since(start) < 250*time.Millisecond, "the pipeline must not wait for the token endpoint")
+
+	herr, ok := errors.Cause(err).(*herodot.DefaultError)
+	require.True(t, ok, "%+v", err)
+	assert.Equal(t, http.StatusGatewayTimeout, herr.StatusCode())
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the request to the token endpoint was not cancelled")
+	}
+}
+
+func TestRequestHandlerIPFilter(t *testing.T) {
+	for k, tc := range []struct {
+		d          string
+		setup      func()
+		filter     *rule.IPFilter
+		remoteAddr string
+		forwarded  string
+		expectErr  bool
+	}{
+		{
+			d:          "should pass without filters",
+			remoteAddr: "10.0.0.1:1234",
+		},
+		{
+			d:          "should pass because the address is allowed globally",
+			setup:      func() { viper.Set(configuration.ViperKeyAccessRuleIPFilterAllow, []string{"10.0.0.0/8"}) },
+			remoteAddr: "10.0.0.1:1234",
+		},
+		{
+			d:          "should fail because the address is not allowed globally",
+			setup:      func() { viper.Set(configuration.ViperKeyAccessRuleIPFilterAllow, []string{"10.0.0.0/8"}) },
+			remoteAddr: "192.168.0.1:1234",
+			expectErr:  true,
+		},
+		{
+			d: "should fail because the address is denied globally",
+			setup: func() {
+				viper.Set(configuration.ViperKeyAccessRuleIPFilterAllow, []string{"10.0.0.0/8"})
+				viper.Set(configuration.ViperKeyAccessRuleIPFilterDeny, []string{"10.0.0.1"})
+			},
+			remoteAddr: "10.0.0.1:1234",
+			expectErr:  true,
+		},
+		{
+			d:          "should fail because the address is denied by the rule",
+			filter:     &rule.IPFilter{Deny: []string{"10.0.0.0/24"}},
+			remoteAddr: "10.0.0.1:1234",
+			expectErr:  true,
+		},
+		{
+			d:          "should fail because the address is not allowed by the rule",
+			setup:      func() { viper.Set(configuration.ViperKeyAccessRuleIPFilterAllow, []string{"10.0.0.0/8"}) },
+			filter:     &rule.IPFilter{Allow: []string{"10.1.0.0/16"}},
+			remoteAddr: "10.0.0.1:1234",
+			expectErr:  true,
+		},
+		{
+			d:          "should ignore the X-Forwarded-For header of untrusted clients",
+			filter:     &rule.IPFilter{Allow: []string{"10.0.0.0/8"}},
+			remoteAddr: "192.168.0.1:1234",
+			forwarded:  "10.0.0.1",
+			expectErr:  true,
+		},
+		{
+			d:          "should use the X-Forwarded-For header of trusted proxies",
+			setup:      func() { viper.Set(configuration.ViperKeyAccessRuleIPFilterTrustedProxies, []string{"192.168.0.0/16"}) },
+			filter:     &rule.IPFilter{Deny: []string{"10.0.0.1"}},
+			remoteAddr: "192.168.0.1:1234",
+			forwarded:  "10.0.0.2, 10.0.0.1, 192.168.0.2",
+			expectErr:  true,
+		},
+		{
+			d:          "should not use addresses in the X-Forwarded-For header which were added by untrusted clients",
+			setup:      func() { viper.Set(configuration.ViperKeyAccessRuleIPFilterTrustedProxies, []string{"192.168.0.0/16"}) },
+			filter:     &rule.IPFilter{Deny: []string{"10.0.0.2"}},
+			remoteAddr: "192.168.0.1:1234",
+			forwarded:  "10.0.0.2, 10.0.0.1",
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+			conf := internal.NewConfigurationWithDefaults()
+			reg := internal.NewRegistry(conf)
+
+			viper.Set(configuration.ViperKeyAuthenticatorAnonymousIsEnabled, true)
+			viper.Set(configuration.ViperKeyAuthorizerAllowIsEnabled, true)
+			viper.Set(configuration.ViperKeyMutatorNoopIsEnabled, true)
+			if tc.setup != nil {
+				tc.setup()
+			}
+
+			req := newTestRequest("http://localhost")
+			req.RemoteAddr = tc.remoteAddr
+			req.Header = http.Header{}
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+
+			_, err := reg.ProxyRequestHandler().HandleRequest(req, &rule.Rule{
+				IPFilter:       tc.filter,
+				Authenticators: []rule.Handler{{Handler: "anonymous"}},
+				Authorizer:     rule.Handler{Handler: "allow"},
+				Mutators:       []rule.Handler{{Handler: "noop"}},
+			})
+			if !tc.expectErr {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			herr, ok := errors.Cause(err).(*herodot.DefaultError)
+			require.True(t, ok, "%+v", err)
+			assert.Equal(t, http.StatusForbidden, herr.StatusCode())
+		})
+	}
+}
+
 func TestInitializeSession(t *testing.T) {
 	for k, tc := range []struct {
 		d                string
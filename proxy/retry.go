@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/x"
+)
+
+// idempotentMethods are the methods of requests which may be retried.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+type retryRegistry interface {
+	x.RegistryLogger
+}
+
+// retryTransport retries requests to the upstream which failed or returned one of the configured status codes. Only
+// requests with an idempotent method and without a body are retried. Attempts are spaced out by a randomized
+// exponential backoff and stop once the request context or the configured total timeout expires. The timeout does not
+// limit reading the body of the response which is returned.
+type retryTransport struct {
+	r    retryRegistry
+	c    configuration.Provider
+	next http.RoundTripper
+}
+
+func isRetryable(r *http.Request) bool {
+	return idempotentMethods[r.Method] &&
+		(r.Body == nil || r.Body == http.NoBody) &&
+		r.Header.Get("Upgrade") == ""
+}
+
+func (t *retryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c := t.c.ProxyUpstreamRetry()
+	if c.MaxAttempts <= 1 || !isRetryable(r) {
+		return t.next.RoundTrip(r)
+	}
+
+	// The timeout covers the attempts and the backoffs between them but not reading the body of the response which
+	// is returned, which is why it is enforced by a timer that is stopped once a response was accepted.
+	parent := r.Context()
+	ctx, cancel := context.WithCancel(parent)
+	r = r.WithContext(ctx)
+
+	var deadline time.Time
+	var budget *time.Timer
+	if c.Timeout > 0 {
+		deadline = time.Now().Add(c.Timeout)
+		budget = time.AfterFunc(c.Timeout, cancel)
+	}
+	stopBudget := func() bool {
+		return budget == nil || budget.Stop()
+	}
+
+	// previous is the last response which was retried. It is returned if no further attempt completes in time.
+	var previous *http.Response
+	returnPrevious := func() (*http.Response, error) {
+		stopBudget()
+		cancel()
+		return previous, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := t.attempt(r, c)
+		if err != nil && previous != nil && ctx.Err() != nil && parent.Err() == nil {
+			// The attempt failed only because the timeout expired.
+			return returnPrevious()
+		}
+
+		backoff := c.Backoff(attempt)
+		last := attempt >= c.MaxAttempts || !canWait(ctx, deadline, backoff)
+		if err == nil && (last || !c.RetryOn(res.StatusCode)) {
+			if !stopBudget() && previous != nil {
+				// The timeout expired while the response was received, its body can no longer be read.
+				_ = res.Body.Close()
+				return returnPrevious()
+			}
+
+			res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+			return res, nil
+		} else if err != nil && last {
+			stopBudget()
+			cancel()
+			return nil, err
+		}
+
+		l := t.r.Logger().
+			WithField("http_method", r.Method).
+			WithField("http_url", r.URL.String()).
+			WithField("attempt", attempt).
+			WithField("max_attempts", c.MaxAttempts).
+			WithField("backoff", backoff.String())
+		if err != nil {
+			l = l.WithError(err)
+		} else {
+			l = l.WithField("status_code", res.StatusCode)
+			previous = bufferResponse(res)
+		}
+		l.Info("Retrying request to upstream")
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			if previous != nil && parent.Err() == nil {
+				return returnPrevious()
+			}
+			stopBudget()
+			cancel()
+			return nil, errors.WithStack(ctx.Err())
+		}
+	}
+}
+
+// retryBufferLimit is the maximum size of a response body which is kept in memory while the request is retried.
+const retryBufferLimit = 64 << 10
+
+// bufferResponse reads and closes the body of res. It returns res with the body held in memory, or nil if the body
+// is too large or can not be read.
+func bufferResponse(res *http.Response) *http.Response {
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, retryBufferLimit+1))
+	if err != nil || len(body) > retryBufferLimit {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		return nil
+	}
+
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return res
+}
+
+// canWait returns false if ctx is done or ctx or the deadline expire before d has passed, in which case there is no
+// time left for another attempt.
+func canWait(ctx context.Context, deadline time.Time, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if parent, ok := ctx.Deadline(); ok && (deadline.IsZero() || parent.Before(deadline)) {
+		deadline = parent
+	}
+	return deadline.IsZero() || time.Now().Add(d).Before(deadline)
+}
+
+func (t *retryTransport) attempt(r *http.Request, c *configuration.UpstreamRetryConfig) (*http.Response, error) {
+	if c.PerTryTimeout <= 0 {
+		return t.next.RoundTrip(r)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), c.PerTryTimeout)
+	res, err := t.next.RoundTrip(r.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout applies until the response body was read.
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,271 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/x"
+)
+
+type retryTestProvider struct {
+	configuration.Provider
+	c *configuration.UpstreamRetryConfig
+}
+
+func (p *retryTestProvider) ProxyUpstreamRetry() *configuration.UpstreamRetryConfig {
+	return p.c
+}
+
+func TestRetryTransport(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/unavailable":
+			if call < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case "/slow":
+			if call == 1 {
+				select {
+				case <-time.After(time.Second):
+				case <-r.Context().Done():
+				}
+				return
+			}
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer backend.Close()
+
+	for k, tc := range []struct {
+		method       string
+		path         string
+		body         string
+		config       configuration.UpstreamRetryConfig
+		expectStatus int
+		expectCalls  int32
+		minDuration  time.Duration
+	}{
+		{
+			method:       "GET",
+			path:         "/unavailable",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 1, StatusCodes: []int{503}},
+			expectStatus: http.StatusServiceUnavailable,
+			expectCalls:  1,
+		},
+		{
+			method:       "GET",
+			path:         "/unavailable",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{503}},
+			expectStatus: http.StatusOK,
+			expectCalls:  3,
+		},
+		{
+			method:       "GET",
+			path:         "/unavailable",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 2, StatusCodes: []int{503}},
+			expectStatus: http.StatusServiceUnavailable,
+			expectCalls:  2,
+		},
+		{
+			method:       "GET",
+			path:         "/unavailable",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{502}},
+			expectStatus: http.StatusServiceUnavailable,
+			expectCalls:  1,
+		},
+		{
+			method:       "POST",
+			path:         "/unavailable",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{503}},
+			expectStatus: http.StatusServiceUnavailable,
+			expectCalls:  1,
+		},
+		{
+			method:       "PUT",
+			path:         "/unavailable",
+			body:         "payload",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{503}},
+			expectStatus: http.StatusServiceUnavailable,
+			expectCalls:  1,
+		},
+		{
+			method:       "GET",
+			path:         "/slow",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 2, PerTryTimeout: 50 * time.Millisecond},
+			expectStatus: http.StatusOK,
+			expectCalls:  2,
+		},
+		{
+			method:       "GET",
+			path:         "/unavailable",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{503}, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			expectStatus: http.StatusOK,
+			expectCalls:  3,
+			// The backoffs are at least 50ms and 100ms.
+			minDuration: 150 * time.Millisecond,
+		},
+		{
+			method:       "GET",
+			path:         "/down",
+			config:       configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{503}, InitialBackoff: 300 * time.Millisecond, Timeout: 100 * time.Millisecond},
+			expectStatus: http.StatusServiceUnavailable,
+			// Even the shortest backoff after the first attempt (150ms) exceeds the timeout.
+			expectCalls: 1,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			config := tc.config
+			rt := &retryTransport{
+				r:    new(x.TestLoggerProvider),
+				c:    &retryTestProvider{c: &config},
+				next: http.DefaultTransport,
+			}
+
+			req, err := http.NewRequest(tc.method, backend.URL+tc.path, nil)
+			require.NoError(t, err)
+			if tc.body != "" {
+				req.Body = ioutil.NopCloser(strings.NewReader(tc.body))
+			}
+
+			start := time.Now()
+			res, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			assert.True(t, time.Since(start) >= tc.minDuration, "expected the retries to take at least %s but they took %s", tc.minDuration, time.Since(start))
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tc.expectStatus, res.StatusCode)
+			assert.Equal(t, tc.expectCalls, atomic.LoadInt32(&calls))
+			if tc.expectStatus == http.StatusOK {
+				assert.Equal(t, "ok", string(body))
+			}
+		})
+	}
+}
+
+func TestRetryTransportTimeout(t *testing.T) {
+	var calls int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(&calls, 1)
+		switch r.URL.Path {
+		case "/hanging":
+			if call > 1 {
+				<-r.Context().Done()
+				return
+			}
+		case "/streaming":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(150 * time.Millisecond)
+			fmt.Fprint(w, "ok")
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "unavailable")
+	}))
+	defer backend.Close()
+
+	newTransport := func(c *configuration.UpstreamRetryConfig) *retryTransport {
+		return &retryTransport{r: new(x.TestLoggerProvider), c: &retryTestProvider{c: c}, next: http.DefaultTransport}
+	}
+
+	t.Run("case=should stop retrying once the timeout expires", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		rt := newTransport(&configuration.UpstreamRetryConfig{
+			MaxAttempts:    100,
+			StatusCodes:    []int{503},
+			InitialBackoff: 20 * time.Millisecond,
+			MaxBackoff:     20 * time.Millisecond,
+			Timeout:        200 * time.Millisecond,
+		})
+		req, err := http.NewRequest("GET", backend.URL, nil)
+		require.NoError(t, err)
+
+		start := time.Now()
+		res, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, "unavailable", string(body))
+		assert.True(t, time.Since(start) < time.Second, "took %s", time.Since(start))
+		assert.True(t, atomic.LoadInt32(&calls) > 1)
+		assert.True(t, atomic.LoadInt32(&calls) < 100)
+	})
+
+	t.Run("case=should return the previous response if the last attempt runs out of time", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		rt := newTransport(&configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{503}, Timeout: 100 * time.Millisecond})
+		req, err := http.NewRequest("GET", backend.URL+"/hanging", nil)
+		require.NoError(t, err)
+
+		res, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, "unavailable", string(body))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("case=should not limit reading the body of the accepted response", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		rt := newTransport(&configuration.UpstreamRetryConfig{MaxAttempts: 3, StatusCodes: []int{503}, Timeout: 50 * time.Millisecond})
+		req, err := http.NewRequest("GET", backend.URL+"/streaming", nil)
+		require.NoError(t, err)
+
+		res, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "ok", string(body))
+	})
+
+	t.Run("case=should stop retrying once the request is cancelled", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		rt := newTransport(&configuration.UpstreamRetryConfig{
+			MaxAttempts:    100,
+			StatusCodes:    []int{503},
+			InitialBackoff: 20 * time.Millisecond,
+			MaxBackoff:     20 * time.Millisecond,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, "GET", backend.URL, nil)
+		require.NoError(t, err)
+
+		start := time.Now()
+		res, err := rt.RoundTrip(req)
+		if err == nil {
+			require.NoError(t, res.Body.Close())
+		}
+		assert.True(t, time.Since(start) < 150*time.Millisecond, "took %s", time.Since(start))
+		assert.True(t, atomic.LoadInt32(&calls) < 100)
+	})
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/helper"
+)
+
+// valuesContext keeps the values of a context but takes the deadline and cancellation from another one.
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c *valuesContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
+
+// pipelineDeadline returns the point in time at which the pipeline budget of a request started now is exhausted. It
+// returns the zero time if no budget is configured.
+func (d *RequestHandler) pipelineDeadline() time.Time {
+	if timeout := d.c.AccessRulePipelineTimeout(); timeout > 0 {
+		return time.Now().Add(timeout)
+	}
+	return time.Time{}
+}
+
+// runHandler executes a pipeline handler. The context of the request expires after the handler's timeout or at the
+// pipeline deadline, whichever comes first. If it expires, an ErrPipelineTimeout is returned.
+func runHandler(r *http.Request, deadline time.Time, timeout time.Duration, handlerType, handler string, f func() error) error {
+	if timeout > 0 {
+		if d := time.Now().Add(timeout); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+
+	if deadline.IsZero() {
+		return f()
+	}
+
+	if !time.Now().Before(deadline) {
+		return newErrPipelineTimeout(handlerType, handler)
+	}
+
+	parent := r.Context()
+	ctx, cancel := context.WithDeadline(parent, deadline)
+	defer cancel()
+
+	*r = *r.WithContext(ctx)
+	err := f()
+
+	// Handlers may add values to the context (e.g. the correlation ID) but the deadline must not apply to the
+	// request forwarded to the upstream.
+	*r = *r.WithContext(&valuesContext{Context: parent, values: r.Context()})
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return newErrPipelineTimeout(handlerType, handler)
+	}
+	return err
+}
+
+func newErrPipelineTimeout(handlerType, handler string) error {
+	return errors.WithStack(helper.ErrPipelineTimeout.WithReasonf(`The %s "%s" did not complete in time.`, handlerType, handler))
+}
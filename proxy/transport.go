@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+)
+
+// NewUpstreamTransport returns the transport used to forward requests to upstreams. It behaves like
+// http.DefaultTransport unless configured otherwise. Upstreams with the "h2c" URL scheme are reached over
+// cleartext HTTP/2.
+func NewUpstreamTransport(c *configuration.UpstreamTransportConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = c.MaxIdleConns
+	t.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	t.IdleConnTimeout = c.IdleConnTimeout
+	t.TLSHandshakeTimeout = c.TLSHandshakeTimeout
+	t.ForceAttemptHTTP2 = c.HTTP2
+	if !c.HTTP2 {
+		// A non-nil, empty map disables HTTP/2.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	t.RegisterProtocol("h2c", newH2CTransport())
+
+	if len(c.CertificateAuthorities) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		for _, path := range c.CertificateAuthorities {
+			pem, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.Errorf(`file "%s" does not contain any PEM encoded certificates`, path)
+			}
+		}
+
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return t, nil
+}
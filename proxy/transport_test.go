@@ -0,0 +1,83 @@
+package proxy_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/proxy"
+)
+
+func TestNewUpstreamTransport(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	ca, err := ioutil.TempFile("", "upstream-ca-*.pem")
+	require.NoError(t, err)
+	defer os.Remove(ca.Name())
+	require.NoError(t, pem.Encode(ca, &pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+	require.NoError(t, ca.Close())
+
+	config := func(cas ...string) *configuration.UpstreamTransportConfig {
+		return &configuration.UpstreamTransportConfig{
+			MaxIdleConns:           10,
+			MaxIdleConnsPerHost:    5,
+			IdleConnTimeout:        time.Minute,
+			TLSHandshakeTimeout:    time.Second,
+			HTTP2:                  true,
+			CertificateAuthorities: cas,
+		}
+	}
+
+	t.Run("case=applies settings", func(t *testing.T) {
+		c := config()
+		c.HTTP2 = false
+
+		tr, err := proxy.NewUpstreamTransport(c)
+		require.NoError(t, err)
+		assert.Equal(t, 10, tr.MaxIdleConns)
+		assert.Equal(t, 5, tr.MaxIdleConnsPerHost)
+		assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+		assert.Equal(t, time.Second, tr.TLSHandshakeTimeout)
+		assert.False(t, tr.ForceAttemptHTTP2)
+		assert.NotNil(t, tr.TLSNextProto)
+		assert.Empty(t, tr.TLSNextProto)
+	})
+
+	t.Run("case=rejects upstream with unknown certificate authority", func(t *testing.T) {
+		tr, err := proxy.NewUpstreamTransport(config())
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		require.NoError(t, err)
+		_, err = tr.RoundTrip(req)
+		require.Error(t, err)
+	})
+
+	t.Run("case=trusts configured certificate authority", func(t *testing.T) {
+		tr, err := proxy.NewUpstreamTransport(config(ca.Name()))
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		require.NoError(t, err)
+		res, err := tr.RoundTrip(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("case=fails on invalid certificate authority", func(t *testing.T) {
+		_, err := proxy.NewUpstreamTransport(config("does-not-exist.pem"))
+		require.Error(t, err)
+	})
+}
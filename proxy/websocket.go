@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ory/x/logrusx"
+)
+
+const (
+	websocketOpcodeClose = 0x8
+
+	// websocketCloseNoStatus is reported if the connection was closed without a close frame containing a status code.
+	websocketCloseNoStatus = 1005
+)
+
+// IsWebSocketUpgrade returns true if the response switches the connection to the WebSocket protocol.
+func IsWebSocketUpgrade(res *http.Response) bool {
+	return res.StatusCode == http.StatusSwitchingProtocols &&
+		strings.EqualFold(res.Header.Get("Upgrade"), "websocket")
+}
+
+// websocketConn wraps the upgraded connection to the upstream and logs a summary of the WebSocket session when it
+// is closed. Individual frames are not logged.
+type websocketConn struct {
+	io.ReadWriteCloser
+
+	l      *logrusx.Logger
+	fields map[string]interface{}
+	start  time.Time
+
+	bytesIn  int64
+	bytesOut int64
+
+	fromClient websocketCloseScanner
+	fromServer websocketCloseScanner
+
+	once sync.Once
+}
+
+func newWebSocketConn(rwc io.ReadWriteCloser, l *logrusx.Logger, fields map[string]interface{}) *websocketConn {
+	l.WithFields(fields).Info("WebSocket connection established")
+	return &websocketConn{ReadWriteCloser: rwc, l: l, fields: fields, start: time.Now()}
+}
+
+// Read reads data sent by the upstream to the client.
+func (c *websocketConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	atomic.AddInt64(&c.bytesOut, int64(n))
+	c.fromServer.scan(p[:n])
+	return n, err
+}
+
+// Write writes data sent by the client to the upstream.
+func (c *websocketConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+	atomic.AddInt64(&c.bytesIn, int64(n))
+	c.fromClient.scan(p[:n])
+	return n, err
+}
+
+func (c *websocketConn) Close() error {
+	err := c.ReadWriteCloser.Close()
+	c.once.Do(func() {
+		closeCode := c.fromClient.code()
+		if closeCode == websocketCloseNoStatus {
+			closeCode = c.fromServer.code()
+		}
+
+		c.l.WithFields(c.fields).
+			WithField("duration", time.Since(c.start).String()).
+			WithField("bytes_in", atomic.LoadInt64(&c.bytesIn)).
+			WithField("bytes_out", atomic.LoadInt64(&c.bytesOut)).
+			WithField("close_code", closeCode).
+			Info("WebSocket connection closed")
+	})
+	return err
+}
+
+// websocketCloseScanner follows the frames sent in one direction of a WebSocket connection and records the status
+// code of the first close frame.
+type websocketCloseScanner struct {
+	sync.Mutex
+
+	header    []byte
+	remaining uint64
+	offset    uint64
+	isClose   bool
+	masked    bool
+	mask      [4]byte
+	status    [2]byte
+	closeCode int
+}
+
+func (s *websocketCloseScanner) code() int {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closeCode == 0 {
+		return websocketCloseNoStatus
+	}
+	return s.closeCode
+}
+
+func (s *websocketCloseScanner) scan(p []byte) {
+	s.Lock()
+	defer s.Unlock()
+
+	for len(p) > 0 {
+		if s.remaining > 0 {
+			n := uint64(len(p))
+			if n > s.remaining {
+				n = s.remaining
+			}
+
+			for i := uint64(0); s.isClose && s.closeCode == 0 && i < n && s.offset < 2; i++ {
+				b := p[i]
+				if s.masked {
+					b ^= s.mask[s.offset%4]
+				}
+				s.status[s.offset] = b
+				s.offset++
+				if s.offset == 2 {
+					s.closeCode = int(binary.BigEndian.Uint16(s.status[:]))
+				}
+			}
+
+			s.remaining -= n
+			p = p[n:]
+			continue
+		}
+
+		s.header = append(s.header, p[0])
+		p = p[1:]
+		if size, ok := s.headerSize(); ok && len(s.header) == size {
+			s.startFrame()
+		}
+	}
+}
+
+func (s *websocketCloseScanner) headerSize() (int, bool) {
+	if len(s.header) < 2 {
+		return 0, false
+	}
+
+	size := 2
+	switch s.header[1] & 0x7f {
+	case 126:
+		size += 2
+	case 127:
+		size += 8
+	}
+	if s.header[1]&0x80 != 0 {
+		size += 4
+	}
+	return size, true
+}
+
+func (s *websocketCloseScanner) startFrame() {
+	s.isClose = s.header[0]&0x0f == websocketOpcodeClose
+	s.masked = s.header[1]&0x80 != 0
+	s.offset = 0
+
+	rest := s.header[2:]
+	switch length := s.header[1] & 0x7f; length {
+	case 126:
+		s.remaining = uint64(binary.BigEndian.Uint16(rest))
+		rest = rest[2:]
+	case 127:
+		s.remaining = binary.BigEndian.Uint64(rest)
+		rest = rest[8:]
+	default:
+		s.remaining = uint64(length)
+	}
+
+	if s.masked {
+		copy(s.mask[:], rest)
+	}
+
+	s.header = s.header[:0]
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	assert.True(t, IsWebSocketUpgrade(&http.Response{StatusCode: http.StatusSwitchingProtocols, Header: http.Header{"Upgrade": {"WebSocket"}}}))
+	assert.False(t, IsWebSocketUpgrade(&http.Response{StatusCode: http.StatusSwitchingProtocols, Header: http.Header{"Upgrade": {"h2c"}}}))
+	assert.False(t, IsWebSocketUpgrade(&http.Response{StatusCode: http.StatusOK, Header: http.Header{"Upgrade": {"websocket"}}}))
+}
+
+func TestWebSocketCloseScanner(t *testing.T) {
+	// An unmasked text frame with payload "hello" followed by an unmasked close frame with status 1001
+	unmasked := []byte{0x81, 0x05, 'h', 'e', 'l', 'l', 'o', 0x88, 0x02, 0x03, 0xe9}
+
+	// A masked text frame with a 126 byte payload followed by a masked close frame with status 1000 and reason "bye"
+	mask := []byte{0x01, 0x02, 0x03, 0x04}
+	masked := append([]byte{0x81, 0xfe, 0x00, 0x7e}, mask...)
+	masked = append(masked, make([]byte, 126)...)
+	closePayload := []byte{0x03, 0xe8, 'b', 'y', 'e'}
+	masked = append(masked, 0x88, 0x85)
+	masked = append(masked, mask...)
+	for k, b := range closePayload {
+		masked = append(masked, b^mask[k%4])
+	}
+
+	for k, tc := range []struct {
+		frames    []byte
+		chunkSize int
+		expect    int
+	}{
+		{frames: unmasked, chunkSize: len(unmasked), expect: 1001},
+		{frames: unmasked, chunkSize: 1, expect: 1001},
+		{frames: masked, chunkSize: len(masked), expect: 1000},
+		{frames: masked, chunkSize: 3, expect: 1000},
+		{frames: unmasked[:7], chunkSize: 7, expect: websocketCloseNoStatus},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			var s websocketCloseScanner
+			for i := 0; i < len(tc.frames); i += tc.chunkSize {
+				end := i + tc.chunkSize
+				if end > len(tc.frames) {
+					end = len(tc.frames)
+				}
+				s.scan(tc.frames[i:end])
+			}
+			assert.Equal(t, tc.expect, s.code())
+		})
+	}
+}
@@ -24,4 +24,18 @@ import "context"
 
 type Fetcher interface {
 	Watch(ctx context.Context) error
+	Reload(ctx context.Context) ([]SourceStatus, error)
+	Status() []SourceStatus
+}
+
+// SourceStatus is the outcome of fetching the access rules of a single repository.
+type SourceStatus struct {
+	// Source is the location of the repository. Passwords are redacted.
+	Source string `json:"source"`
+
+	// Rules is the number of access rules fetched from the repository.
+	Rules int `json:"rules"`
+
+	// Error is set if the access rules could not be fetched.
+	Error string `json:"error,omitempty"`
 }
@@ -3,6 +3,7 @@ package rule
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"io"
@@ -57,13 +58,21 @@ type FetcherDefault struct {
 	r  fetcherRegistry
 	hc *http.Client
 
-	cache map[string][]Rule
+	cache       map[string][]Rule
+	fetchErrors map[string]error
+	dbs         map[string]*sql.DB
+
+	stopSQLWatchers context.CancelFunc
 
 	directoriesBeingWatched []string
 	filesBeingWatched       []string
 
 	lock sync.Mutex
 	wg   sync.WaitGroup
+
+	// dbLock is held for reading while a database handle of dbs is in use and for writing while the handles are
+	// closed, so that a reload never queries a closed handle.
+	dbLock sync.RWMutex
 }
 
 func NewFetcherDefault(
@@ -71,10 +80,12 @@ func NewFetcherDefault(
 	r fetcherRegistry,
 ) *FetcherDefault {
 	return &FetcherDefault{
-		r:     r,
-		c:     c,
-		hc:    httpx.NewResilientClientLatencyToleranceHigh(nil),
-		cache: map[string][]Rule{},
+		r:           r,
+		c:           c,
+		hc:          httpx.NewResilientClientLatencyToleranceHigh(nil),
+		cache:       map[string][]Rule{},
+		fetchErrors: map[string]error{},
+		dbs:         map[string]*sql.DB{},
 	}
 }
 
@@ -125,7 +136,24 @@ func (f *FetcherDefault) configUpdate(ctx context.Context, watcher *fsnotify.Wat
 
 	// And we need to reset the rule cache
 	f.cache = make(map[string][]Rule)
+	f.fetchErrors = make(map[string]error)
+	f.lock.Unlock()
+
+	// SQL repositories are not watched by fsnotify but polled or notified, so we restart those watchers
+	f.closeSQL()
+	sqlCtx, stopSQLWatchers := context.WithCancel(ctx)
+	f.lock.Lock()
+	f.stopSQLWatchers = stopSQLWatchers
 	f.lock.Unlock()
+	for _, source := range replace {
+		if !isSQLSource(source) {
+			continue
+		}
+
+		if err := f.watchSQL(sqlCtx, source, events); err != nil {
+			f.r.Logger().WithError(err).WithField("file", redactURL(source)).Error("Not watching access rule repository for changes. Check the configuration or restart the service if the issue persists.")
+		}
+	}
 
 	// If there are no more sources to watch we reset the rule repository as a whole
 	if len(replace) == 0 {
@@ -144,31 +172,121 @@ func (f *FetcherDefault) configUpdate(ctx context.Context, watcher *fsnotify.Wat
 }
 
 func (f *FetcherDefault) sourceUpdate(e event) ([]Rule, error) {
-	if e.path.Scheme == "file" {
-		u, err := url.Parse("file://" + filepath.Clean(strings.TrimPrefix(e.path.String(), "file://")))
-		if err != nil {
-			return nil, err
-		}
+	if _, err := f.fetchAndCache(e.path); err != nil {
+		return nil, err
+	}
 
-		e.path = *u
+	return f.cachedRules(), nil
+}
+
+// normalizeSource cleans the path of file sources so that they can be used as cache keys.
+func normalizeSource(source url.URL) (url.URL, error) {
+	if source.Scheme != "file" {
+		return source, nil
+	}
+
+	u, err := url.Parse("file://" + filepath.Clean(strings.TrimPrefix(source.String(), "file://")))
+	if err != nil {
+		return source, err
+	}
+
+	return *u, nil
+}
+
+// fetchAndCache fetches the access rules from source and replaces the previously cached rules of that source.
+func (f *FetcherDefault) fetchAndCache(source url.URL) ([]Rule, error) {
+	source, err := normalizeSource(source)
+	if err != nil {
+		return nil, err
 	}
 
-	rules, err := f.fetch(e.path)
+	rules, err := f.fetch(source)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
 	if err != nil {
+		f.fetchErrors[source.String()] = err
 		return nil, err
 	}
 
+	delete(f.fetchErrors, source.String())
+	f.cache[source.String()] = rules
+
+	return rules, nil
+}
+
+// Status returns the outcome of the last attempt to fetch the access rules of each configured repository.
+func (f *FetcherDefault) Status() []SourceStatus {
+	sources := f.c.AccessRuleRepositories()
+	statuses := make([]SourceStatus, len(sources))
+
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
-	f.cache[e.path.String()] = rules
+	for k, source := range sources {
+		statuses[k] = SourceStatus{Source: redactURL(source)}
+
+		key, err := normalizeSource(source)
+		if err != nil {
+			statuses[k].Error = err.Error()
+			continue
+		}
+
+		if err, ok := f.fetchErrors[key.String()]; ok {
+			statuses[k].Error = err.Error()
+		} else if rules, ok := f.cache[key.String()]; ok {
+			statuses[k].Rules = len(rules)
+		} else {
+			statuses[k].Error = "access rules have not been fetched yet"
+		}
+	}
+
+	return statuses
+}
+
+func (f *FetcherDefault) cachedRules() []Rule {
+	f.lock.Lock()
+	defer f.lock.Unlock()
 
 	var total []Rule
 	for _, items := range f.cache {
 		total = append(total, items...)
 	}
 
-	return total, nil
+	return total
+}
+
+// Reload fetches the access rules from all configured repositories immediately instead of waiting for the next
+// change. Repositories which can not be fetched keep their previously loaded access rules.
+func (f *FetcherDefault) Reload(ctx context.Context) ([]SourceStatus, error) {
+	sources := f.c.AccessRuleRepositories()
+	statuses := make([]SourceStatus, len(sources))
+	for k, source := range sources {
+		statuses[k] = SourceStatus{Source: redactURL(source)}
+
+		rules, err := f.fetchAndCache(source)
+		if err != nil {
+			f.r.Logger().WithError(err).
+				WithField("file", redactURL(source)).
+				Error("Unable to reload access rules from given location, changes will be ignored.")
+			statuses[k].Error = err.Error()
+			continue
+		}
+
+		statuses[k].Rules = len(rules)
+	}
+
+	total := f.cachedRules()
+	if total == nil {
+		total = []Rule{}
+	}
+
+	if err := f.r.RuleRepository().Set(ctx, total); err != nil {
+		return nil, errors.Wrapf(err, "unable to reset access rule repository")
+	}
+
+	return statuses, nil
 }
 
 func (f *FetcherDefault) Watch(ctx context.Context) error {
@@ -180,6 +298,7 @@ func (f *FetcherDefault) Watch(ctx context.Context) error {
 
 	events := make(chan event)
 	err = f.watch(ctx, watcher, events)
+	f.closeSQL()
 
 	// Close the channel only when all child goroutines exit
 	f.wg.Wait()
@@ -280,13 +399,13 @@ func (f *FetcherDefault) watch(ctx context.Context, watcher *fsnotify.Watcher, e
 				f.r.Logger().
 					WithField("event", "repository_change").
 					WithField("source", e.source).
-					WithField("file", e.path.String()).
+					WithField("file", redactURL(e.path)).
 					Debugf("One or more access rule repositories changed, reloading access rules.")
 
 				rules, err := f.sourceUpdate(e)
 				if err != nil {
 					f.r.Logger().WithError(err).
-						WithField("file", e.path.String()).
+						WithField("file", redactURL(e.path)).
 						Error("Unable to update access rules from given location, changes will be ignored. Check the configuration or restart the service if the issue persists.")
 					continue
 				}
@@ -310,7 +429,7 @@ func (f *FetcherDefault) enqueueEvent(events chan event, evt event) {
 
 func (f *FetcherDefault) fetch(source url.URL) ([]Rule, error) {
 	f.r.Logger().
-		WithField("location", source.String()).
+		WithField("location", redactURL(source)).
 		Debugf("Fetching access rules from given location because something changed.")
 
 	switch source.Scheme {
@@ -324,6 +443,10 @@ func (f *FetcherDefault) fetch(source url.URL) ([]Rule, error) {
 			return f.fetchFile(p)
 		}
 		return f.fetchDir(p)
+	case "postgres":
+		fallthrough
+	case "postgresql":
+		return f.fetchSQL(source)
 	case "inline":
 		src, err := base64.StdEncoding.DecodeString(strings.Replace(source.String(), "inline://", "", 1))
 		if err != nil {
@@ -0,0 +1,219 @@
+package rule
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const (
+	sqlDefaultTable           = "oathkeeper_rules"
+	sqlDefaultRefreshInterval = 30 * time.Second
+)
+
+var sqlIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
+
+// sqlSource is an access rule repository backed by a PostgreSQL table where each row contains one access rule
+// encoded as JSON in column `rule`.
+type sqlSource struct {
+	dsn             string
+	table           string
+	refreshInterval time.Duration
+	notifyChannel   string
+}
+
+func isSQLSource(source url.URL) bool {
+	return source.Scheme == "postgres" || source.Scheme == "postgresql"
+}
+
+// parseSQLSource removes the query parameters which are specific to ORY Oathkeeper (`table`, `refresh_interval`,
+// `notify_channel`) from the repository URL. The remaining URL is used as the connection string.
+func parseSQLSource(source url.URL) (*sqlSource, error) {
+	s := &sqlSource{table: sqlDefaultTable, refreshInterval: sqlDefaultRefreshInterval}
+
+	query := source.Query()
+	if table := query.Get("table"); table != "" {
+		if !sqlIdentifier.MatchString(table) {
+			return nil, errors.Errorf(`rule: table "%s" is not a valid identifier: %s`, table, redactURL(source))
+		}
+		s.table = table
+	}
+
+	if interval := query.Get("refresh_interval"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule: unable to parse refresh_interval: %s", redactURL(source))
+		}
+		s.refreshInterval = d
+	}
+
+	s.notifyChannel = query.Get("notify_channel")
+	if s.notifyChannel != "" && !sqlIdentifier.MatchString(s.notifyChannel) {
+		return nil, errors.Errorf(`rule: notify_channel "%s" is not a valid identifier: %s`, s.notifyChannel, redactURL(source))
+	}
+
+	query.Del("table")
+	query.Del("refresh_interval")
+	query.Del("notify_channel")
+	source.RawQuery = query.Encode()
+	s.dsn = source.String()
+
+	return s, nil
+}
+
+// redactURL removes the password from the URL so it can be used in errors and logs.
+func redactURL(source url.URL) string {
+	if _, ok := source.User.Password(); ok {
+		source.User = url.UserPassword(source.User.Username(), "xxxxx")
+	}
+	return source.String()
+}
+
+func (f *FetcherDefault) sqlDB(dsn string) (*sql.DB, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if db, ok := f.dbs[dsn]; ok {
+		return db, nil
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	f.dbs[dsn] = db
+	return db, nil
+}
+
+// closeSQL stops all goroutines watching SQL repositories and closes their database connections once they are no
+// longer used by a fetch.
+func (f *FetcherDefault) closeSQL() {
+	f.lock.Lock()
+	if f.stopSQLWatchers != nil {
+		f.stopSQLWatchers()
+		f.stopSQLWatchers = nil
+	}
+
+	// Fetches which start from now on open new handles.
+	dbs := f.dbs
+	f.dbs = map[string]*sql.DB{}
+	f.lock.Unlock()
+
+	f.dbLock.Lock()
+	defer f.dbLock.Unlock()
+
+	for _, db := range dbs {
+		if err := db.Close(); err != nil {
+			f.r.Logger().WithError(err).Warn("Unable to close database connection of access rule repository.")
+		}
+	}
+}
+
+func (f *FetcherDefault) fetchSQL(source url.URL) ([]Rule, error) {
+	s, err := parseSQLSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	f.dbLock.RLock()
+	defer f.dbLock.RUnlock()
+
+	db, err := f.sqlDB(s.dsn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rule: %s", redactURL(source))
+	}
+
+	// The table name is validated in parseSQLSource and can not be passed as a query argument.
+	rows, err := db.Query("SELECT rule FROM " + s.table) // #nosec G202
+	if err != nil {
+		return nil, errors.Wrapf(err, "rule: %s", redactURL(source))
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		var rule Rule
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&rule); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return rules, nil
+}
+
+// watchSQL reloads the access rules of the SQL repository every refresh interval and, if a notify channel is
+// configured, whenever a notification is sent to that channel using PostgreSQL's LISTEN/NOTIFY.
+func (f *FetcherDefault) watchSQL(ctx context.Context, source url.URL, events chan event) error {
+	s, err := parseSQLSource(source)
+	if err != nil {
+		return err
+	}
+
+	var listener *pq.Listener
+	var notifications <-chan *pq.Notification
+	if s.notifyChannel != "" {
+		listener = pq.NewListener(s.dsn, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+			if err != nil {
+				f.r.Logger().WithError(err).
+					WithField("file", redactURL(source)).
+					Warn("Connection used to listen for access rule changes failed, retrying.")
+			}
+		})
+		if err := listener.Listen(s.notifyChannel); err != nil {
+			_ = listener.Close()
+			return errors.Wrapf(err, "rule: unable to listen on notify channel %s", s.notifyChannel)
+		}
+		notifications = listener.Notify
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		if listener != nil {
+			defer listener.Close()
+		}
+
+		var ticks <-chan time.Time
+		if s.refreshInterval > 0 {
+			ticker := time.NewTicker(s.refreshInterval)
+			defer ticker.Stop()
+			ticks = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticks:
+				f.enqueueEvent(events, event{et: eventFileChanged, path: source, source: "sql_poll"})
+			case <-notifications:
+				// A nil notification is sent after the connection was re-established in which case changes
+				// might have been missed, so we reload in both cases.
+				f.enqueueEvent(events, event{et: eventFileChanged, path: source, source: "sql_notify"})
+			}
+		}
+	}()
+
+	return nil
+}
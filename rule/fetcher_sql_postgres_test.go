@@ -0,0 +1,171 @@
+// +build postgres
+
+package rule_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/x/sqlcon/dockertest"
+	"github.com/ory/x/viperx"
+
+	"github.com/ory/oathkeeper/internal"
+)
+
+// These tests require a PostgreSQL database. Run them with `go test -tags postgres ./rule/...`, which starts the
+// database using Docker unless TEST_DATABASE_POSTGRESQL is set.
+
+func connectToTestPostgreSQL(t *testing.T) (*sql.DB, url.URL) {
+	dsn := dockertest.RunTestPostgreSQL(t)
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+
+	for i := 0; i < 60; i++ {
+		if err = db.Ping(); err == nil {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	require.NoError(t, err)
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	return db, *u
+}
+
+func sqlRepository(source url.URL, params map[string]string) string {
+	query := source.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	source.RawQuery = query.Encode()
+	return source.String()
+}
+
+func TestFetcherWatchRepositoryFromSQL(t *testing.T) {
+	db, source := connectToTestPostgreSQL(t)
+	defer db.Close()
+
+	table := "oathkeeper_rules_" + uuid.New().String()[:8]
+	_, err := db.Exec(`CREATE TABLE ` + table + ` (rule JSONB NOT NULL)`)
+	require.NoError(t, err)
+	defer db.Exec(`DROP TABLE ` + table)
+
+	setRules := func(t *testing.T, ids ...string) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		_, err = tx.Exec(`DELETE FROM ` + table)
+		require.NoError(t, err)
+		for _, id := range ids {
+			_, err = tx.Exec(`INSERT INTO `+table+` (rule) VALUES ($1)`, fmt.Sprintf(`{"id":"%s"}`, id))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tx.Commit())
+	}
+
+	conf := internal.NewConfigurationWithDefaults() // this resets viper!!
+	r := internal.NewRegistry(conf)
+
+	id := uuid.New().String()
+	configFile := filepath.Join(os.TempDir(), ".oathkeeper-"+id+".yml")
+	writeConfig := func(t *testing.T, repository string) {
+		require.NoError(t, ioutil.WriteFile(configFile, []byte(`
+access_rules:
+  repositories:
+  - "`+repository+`"
+`), 0666))
+	}
+
+	polled := sqlRepository(source, map[string]string{"table": table, "refresh_interval": "100ms"})
+	notified := sqlRepository(source, map[string]string{"table": table, "refresh_interval": "0", "notify_channel": "rules_" + table[len(table)-8:]})
+
+	writeConfig(t, polled)
+	viperx.InitializeConfig("oathkeeper-"+id, os.TempDir(), nil)
+	viperx.WatchConfig(nil, nil)
+
+	go func() {
+		require.NoError(t, r.RuleFetcher().Watch(context.TODO()))
+	}()
+
+	expectIDs := func(t *testing.T, expect ...string) {
+		rules, err := r.RuleRepository().List(context.Background(), 500, 0)
+		require.NoError(t, err)
+
+		ids := make([]string, len(rules))
+		for k, r := range rules {
+			ids[k] = r.ID
+		}
+		sort.Strings(ids)
+		if len(expect) == 0 {
+			expect = []string{}
+		}
+		assert.Equal(t, expect, ids)
+	}
+
+	t.Run("case=should decode the rows and poll for changes", func(t *testing.T) {
+		for _, ids := range [][]string{{}, {"1"}, {"1", "2"}, {"2", "3"}} {
+			setRules(t, ids...)
+			time.Sleep(time.Millisecond * 500)
+			expectIDs(t, ids...)
+		}
+	})
+
+	t.Run("case=should reload when notified", func(t *testing.T) {
+		writeConfig(t, notified)
+		time.Sleep(time.Millisecond * 500)
+
+		setRules(t, "4")
+		time.Sleep(time.Millisecond * 500)
+		// The refresh interval is disabled so the change must not be visible before the notification.
+		expectIDs(t, "2", "3")
+
+		_, err := db.Exec(`NOTIFY rules_` + table[len(table)-8:])
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond * 500)
+		expectIDs(t, "4")
+	})
+
+	t.Run("case=should not query closed connections while the configuration changes", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				statuses, err := r.RuleFetcher().Reload(ctx)
+				require.NoError(t, err)
+				for _, status := range statuses {
+					assert.Empty(t, status.Error)
+				}
+			}
+		}()
+
+		for i := 0; i < 10; i++ {
+			if i%2 == 0 {
+				writeConfig(t, polled)
+			} else {
+				writeConfig(t, notified)
+			}
+			time.Sleep(time.Millisecond * 100)
+		}
+
+		cancel()
+		wg.Wait()
+		expectIDs(t, "4")
+	})
+}
@@ -23,12 +23,16 @@ package rule
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
 
 	"github.com/pkg/errors"
 
+	"github.com/ory/herodot"
+
 	"github.com/ory/oathkeeper/driver/configuration"
+	"github.com/ory/oathkeeper/x"
 )
 
 type Match struct {
@@ -92,9 +96,15 @@ type Rule struct {
 	// Description is a human readable description of this rule.
 	Description string `json:"description"`
 
+	// Tags are labels used to refer to a group of rules, for example to put only some of them into maintenance mode.
+	Tags []string `json:"tags,omitempty"`
+
 	// Match defines the URL that this rule should match.
 	Match *Match `json:"match"`
 
+	// IPFilter, if set, rejects requests depending on the IP address of the client before the pipeline is executed.
+	IPFilter *IPFilter `json:"ip_filter,omitempty"`
+
 	// Authenticators is a list of authentication handlers that will try and authenticate the provided credentials.
 	// Authenticators are checked iteratively from index 0 to n and if the first authenticator to return a positive
 	// result will be the one used.
@@ -113,6 +123,13 @@ type Rule struct {
 	// Mutations are performed iteratively from index 0 to n and should all succeed in order for the HTTP request to be forwarded.
 	Mutators []Handler `json:"mutators"`
 
+	// ResponseMutators is a list of response mutation handlers that transform the upstream's HTTP response before it is
+	// returned to the client, for example by adding security headers or removing internal headers.
+	//
+	// Response mutations are performed iteratively from index 0 to n. They are not applied by the decisions API
+	// because it does not forward requests to an upstream.
+	ResponseMutators []Handler `json:"response_mutators,omitempty"`
+
 	// Errors is a list of error handlers. These will be invoked if any part of the system returns an error. You can
 	// configure error matchers to listen on certain errors (e.g. unauthorized) and execute specific logic (e.g. redirect
 	// to the login endpoint, return with an XML error, return a json error, ...).
@@ -134,22 +151,117 @@ type Upstream struct {
 
 	// URL is the URL the request will be proxied to.
 	URL string `json:"url"`
+
+	// Mirror, if set, sends a copy of a share of the requests to a shadow upstream. The responses of the shadow
+	// upstream are discarded.
+	Mirror *UpstreamMirror `json:"mirror,omitempty"`
+
+	// Canary, if set, routes a share of the requests to a canary upstream instead of URL.
+	Canary *UpstreamCanary `json:"canary,omitempty"`
+}
+
+// UpstreamCanary configures the canary upstream requests are routed to.
+type UpstreamCanary struct {
+	// URL is the URL of the canary upstream. The path is appended like for the upstream URL.
+	URL string `json:"url"`
+
+	// Weight is the share of requests (0 - 100) which are routed to the canary upstream.
+	Weight float64 `json:"weight"`
+
+	// Header is the name of a request header which overrides the weight. If its value is "always", the request is
+	// routed to the canary upstream, if it is "never", the request is routed to URL.
+	Header string `json:"header,omitempty"`
+}
+
+// UpstreamMirror configures the shadow upstream requests are mirrored to.
+type UpstreamMirror struct {
+	// URL is the URL of the shadow upstream. The path is appended like for the upstream URL.
+	URL string `json:"url"`
+
+	// Percentage is the share of requests (0 - 100) which are mirrored.
+	Percentage float64 `json:"percentage"`
+}
+
+// IPFilter allows or denies requests depending on the IP address of the client.
+type IPFilter struct {
+	// Allow is a list of networks in CIDR notation or IP addresses. If set, requests from other IP addresses are denied.
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny is a list of networks in CIDR notation or IP addresses requests from which are denied. It takes precedence
+	// over Allow.
+	Deny []string `json:"deny,omitempty"`
+
+	// allow and deny are parsed once when the rule is loaded instead of on every request.
+	allow, deny []*net.IPNet
+	parsed      bool
+}
+
+var _ json.Unmarshaler = new(IPFilter)
+
+func (f *IPFilter) UnmarshalJSON(raw []byte) error {
+	var ff struct {
+		Allow []string `json:"allow,omitempty"`
+		Deny  []string `json:"deny,omitempty"`
+	}
+
+	if err := errors.WithStack(json.Unmarshal(raw, &ff)); err != nil {
+		return err
+	}
+
+	allow, deny, err := parseIPFilter(ff.Allow, ff.Deny)
+	if err != nil {
+		return err
+	}
+
+	*f = IPFilter{Allow: ff.Allow, Deny: ff.Deny, allow: allow, deny: deny, parsed: true}
+	return nil
+}
+
+// Networks returns the parsed networks of Allow and Deny. An error is returned if one of them is invalid.
+func (f *IPFilter) Networks() (allow, deny []*net.IPNet, err error) {
+	if f.parsed {
+		return f.allow, f.deny, nil
+	}
+
+	// The filter was not decoded from JSON, for example because it was created in code.
+	return parseIPFilter(f.Allow, f.Deny)
+}
+
+func parseIPFilter(allowed, denied []string) (allow, deny []*net.IPNet, err error) {
+	for _, network := range allowed {
+		n, err := x.ParseNetwork(network)
+		if err != nil {
+			return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Value "%s" of "ip_filter.allow" is not a valid network or IP address.`, network))
+		}
+		allow = append(allow, n)
+	}
+	for _, network := range denied {
+		n, err := x.ParseNetwork(network)
+		if err != nil {
+			return nil, nil, errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Value "%s" of "ip_filter.deny" is not a valid network or IP address.`, network))
+		}
+		deny = append(deny, n)
+	}
+	return allow, deny, nil
 }
 
 var _ json.Unmarshaler = new(Rule)
 
 func (r *Rule) UnmarshalJSON(raw []byte) error {
 	var rr struct {
-		ID             string         `json:"id"`
-		Version        string         `json:"version"`
-		Description    string         `json:"description"`
-		Match          *Match         `json:"match"`
-		Authenticators []Handler      `json:"authenticators"`
-		Authorizer     Handler        `json:"authorizer"`
-		Mutators       []Handler      `json:"mutators"`
-		Errors         []ErrorHandler `json:"errors"`
-		Upstream       Upstream       `json:"upstream"`
-		matchingEngine MatchingEngine
+		ID               string         `json:"id"`
+		Version          string         `json:"version"`
+		Description      string         `json:"description"`
+		Tags             []string       `json:"tags,omitempty"`
+		Match            *Match         `json:"match"`
+		IPFilter         *IPFilter      `json:"ip_filter,omitempty"`
+		Authenticators   []Handler      `json:"authenticators"`
+		Authorizer       Handler        `json:"authorizer"`
+		Mutators         []Handler      `json:"mutators"`
+		ResponseMutators []Handler      `json:"response_mutators,omitempty"`
+		Errors           []ErrorHandler `json:"errors"`
+		Upstream         Upstream       `json:"upstream"`
+		matchingEngine   MatchingEngine
 	}
 
 	transformed, err := migrateRuleJSON(raw)
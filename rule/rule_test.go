@@ -21,12 +21,16 @@
 package rule
 
 import (
+	"encoding/json"
 	"net/url"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ory/herodot"
+
 	"github.com/ory/oathkeeper/driver/configuration"
 )
 
@@ -186,3 +190,38 @@ func TestRuleWithCustomMethod(t *testing.T) {
 		})
 	}
 }
+
+func TestIPFilterUnmarshalJSON(t *testing.T) {
+	t.Run("case=parses the networks when the rule is loaded", func(t *testing.T) {
+		var r Rule
+		require.NoError(t, json.Unmarshal([]byte(`{"id":"test","ip_filter":{"allow":["10.0.0.0/8"],"deny":["10.0.0.1"]}}`), &r))
+		require.NotNil(t, r.IPFilter)
+		assert.Equal(t, []string{"10.0.0.0/8"}, r.IPFilter.Allow)
+		assert.Equal(t, []string{"10.0.0.1"}, r.IPFilter.Deny)
+		assert.True(t, r.IPFilter.parsed)
+
+		allow, deny, err := r.IPFilter.Networks()
+		require.NoError(t, err)
+		require.Len(t, allow, 1)
+		assert.Equal(t, "10.0.0.0/8", allow[0].String())
+		require.Len(t, deny, 1)
+		assert.Equal(t, "10.0.0.1/32", deny[0].String())
+	})
+
+	t.Run("case=fails if a network is invalid", func(t *testing.T) {
+		var r Rule
+		err := json.Unmarshal([]byte(`{"id":"test","ip_filter":{"deny":["10.0.0.0/33"]}}`), &r)
+		require.Error(t, err)
+		assert.Equal(t, `Value "10.0.0.0/33" of "ip_filter.deny" is not a valid network or IP address.`, errors.Cause(err).(*herodot.DefaultError).ReasonField)
+	})
+
+	t.Run("case=parses filters created in code", func(t *testing.T) {
+		allow, _, err := (&IPFilter{Allow: []string{"10.0.0.1"}}).Networks()
+		require.NoError(t, err)
+		require.Len(t, allow, 1)
+		assert.Equal(t, "10.0.0.1/32", allow[0].String())
+
+		_, _, err = (&IPFilter{Allow: []string{"not-an-ip"}}).Networks()
+		require.Error(t, err)
+	})
+}
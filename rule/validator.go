@@ -29,12 +29,14 @@ import (
 	"github.com/ory/oathkeeper/pipeline/authz"
 	pe "github.com/ory/oathkeeper/pipeline/errors"
 	"github.com/ory/oathkeeper/pipeline/mutate"
+	"github.com/ory/oathkeeper/pipeline/response"
 )
 
 type validatorRegistry interface {
 	authn.Registry
 	authz.Registry
 	mutate.Registry
+	response.Registry
 	pe.Registry
 }
 
@@ -104,6 +106,22 @@ func (v *ValidatorDefault) validateMutators(r *Rule) error {
 	return nil
 }
 
+func (v *ValidatorDefault) validateResponseMutators(r *Rule) error {
+	for k, m := range r.ResponseMutators {
+		mutator, err := v.r.PipelineResponseMutator(m.Handler)
+		if err != nil {
+			return herodot.ErrInternalServerError.WithReasonf(`Value "%s" of "response_mutators[%d]" is not in list of supported response mutators: %v`, m.Handler, k,
+				v.r.AvailablePipelineResponseMutators()).WithTrace(err).WithDebug(err.Error())
+		}
+
+		if err := mutator.Validate(m.Config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (v *ValidatorDefault) validateErrorHandlers(r *Rule) error {
 	for k, m := range r.Errors {
 		mutator, err := v.r.PipelineErrorHandler(m.Handler)
@@ -135,6 +153,28 @@ func (v *ValidatorDefault) Validate(r *Rule) error {
 		return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Value "%s" of "upstream.url" is not a valid url.`, r.Upstream.URL))
 	}
 
+	if m := r.Upstream.Mirror; m != nil {
+		if !govalidator.IsURL(m.URL) {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Value "%s" of "upstream.mirror.url" is not a valid url.`, m.URL))
+		} else if m.Percentage < 0 || m.Percentage > 100 {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Value "%v" of "upstream.mirror.percentage" must be between 0 and 100.`, m.Percentage))
+		}
+	}
+
+	if c := r.Upstream.Canary; c != nil {
+		if !govalidator.IsURL(c.URL) {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Value "%s" of "upstream.canary.url" is not a valid url.`, c.URL))
+		} else if c.Weight < 0 || c.Weight > 100 {
+			return errors.WithStack(herodot.ErrInternalServerError.WithReasonf(`Value "%v" of "upstream.canary.weight" must be between 0 and 100.`, c.Weight))
+		}
+	}
+
+	if f := r.IPFilter; f != nil {
+		if _, _, err := f.Networks(); err != nil {
+			return err
+		}
+	}
+
 	if err := v.validateAuthenticators(r); err != nil {
 		return err
 	}
@@ -147,6 +187,10 @@ func (v *ValidatorDefault) Validate(r *Rule) error {
 		return err
 	}
 
+	if err := v.validateResponseMutators(r); err != nil {
+		return err
+	}
+
 	if err := v.validateErrorHandlers(r); err != nil {
 		return err
 	}
@@ -67,6 +67,50 @@ func TestValidateRule(t *testing.T) {
 			},
 			expectErr: `Value of "authenticators" must be set and can not be an empty array.`,
 		},
+		{
+			r: &Rule{
+				Match:    &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream: Upstream{URL: "https://www.ory.sh", Mirror: &UpstreamMirror{URL: "https://shadow ory.sh", Percentage: 10}},
+			},
+			expectErr: `Value "https://shadow ory.sh" of "upstream.mirror.url" is not a valid url.`,
+		},
+		{
+			r: &Rule{
+				Match:    &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream: Upstream{URL: "https://www.ory.sh", Mirror: &UpstreamMirror{URL: "https://shadow.ory.sh", Percentage: 120}},
+			},
+			expectErr: `Value "120" of "upstream.mirror.percentage" must be between 0 and 100.`,
+		},
+		{
+			r: &Rule{
+				Match:    &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream: Upstream{URL: "https://www.ory.sh", Canary: &UpstreamCanary{URL: "https://canary ory.sh", Weight: 10}},
+			},
+			expectErr: `Value "https://canary ory.sh" of "upstream.canary.url" is not a valid url.`,
+		},
+		{
+			r: &Rule{
+				Match:    &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream: Upstream{URL: "https://www.ory.sh", Canary: &UpstreamCanary{URL: "https://canary.ory.sh", Weight: -1}},
+			},
+			expectErr: `Value "-1" of "upstream.canary.weight" must be between 0 and 100.`,
+		},
+		{
+			r: &Rule{
+				Match:    &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream: Upstream{URL: "https://www.ory.sh"},
+				IPFilter: &IPFilter{Allow: []string{"10.0.0.0/8", "not-an-ip"}},
+			},
+			expectErr: `Value "not-an-ip" of "ip_filter.allow" is not a valid network or IP address.`,
+		},
+		{
+			r: &Rule{
+				Match:    &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream: Upstream{URL: "https://www.ory.sh"},
+				IPFilter: &IPFilter{Deny: []string{"10.0.0.0/33"}},
+			},
+			expectErr: `Value "10.0.0.0/33" of "ip_filter.deny" is not a valid network or IP address.`,
+		},
 		{
 			setup: prep(true, false, false),
 			r: &Rule{
@@ -156,6 +200,44 @@ func TestValidateRule(t *testing.T) {
 			},
 			expectErr: `Mutator "noop" is disabled per configuration.`,
 		},
+		{
+			setup: prep(true, true, true),
+			r: &Rule{
+				Match:            &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream:         Upstream{URL: "https://www.ory.sh"},
+				Authenticators:   []Handler{{Handler: "noop"}},
+				Authorizer:       Handler{Handler: "allow"},
+				Mutators:         []Handler{{Handler: "noop"}},
+				ResponseMutators: []Handler{{Handler: "foo"}},
+			},
+			expectErr: `Value "foo" of "response_mutators[0]" is not in list of supported response mutators`,
+		},
+		{
+			setup: prep(true, true, true),
+			r: &Rule{
+				Match:            &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream:         Upstream{URL: "https://www.ory.sh"},
+				Authenticators:   []Handler{{Handler: "noop"}},
+				Authorizer:       Handler{Handler: "allow"},
+				Mutators:         []Handler{{Handler: "noop"}},
+				ResponseMutators: []Handler{{Handler: "header"}},
+			},
+			expectErr: `Response mutator "header" is disabled per configuration.`,
+		},
+		{
+			setup: func() {
+				prep(true, true, true)()
+				viper.Set(configuration.ViperKeyResponseMutatorHeaderIsEnabled, true)
+			},
+			r: &Rule{
+				Match:            &Match{URL: "https://www.ory.sh", Methods: []string{"POST"}},
+				Upstream:         Upstream{URL: "https://www.ory.sh"},
+				Authenticators:   []Handler{{Handler: "noop"}},
+				Authorizer:       Handler{Handler: "allow"},
+				Mutators:         []Handler{{Handler: "noop"}},
+				ResponseMutators: []Handler{{Handler: "header"}},
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			conf := internal.NewConfigurationWithDefaults()
@@ -0,0 +1,79 @@
+package x
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseNetwork parses a network in CIDR notation. A single IP address is parsed as a network containing only this
+// address.
+func ParseNetwork(network string) (*net.IPNet, error) {
+	if !strings.Contains(network, "/") {
+		ip := net.ParseIP(network)
+		if ip == nil {
+			return nil, errors.Errorf("invalid IP address: %s", network)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, n, err := net.ParseCIDR(network)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return n, nil
+}
+
+// ParseNetworks parses a list of networks using ParseNetwork.
+func ParseNetworks(networks []string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, len(networks))
+	for k, network := range networks {
+		n, err := ParseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		parsed[k] = n
+	}
+	return parsed, nil
+}
+
+// ContainsIP returns true if one of the networks contains the IP address.
+func ContainsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP address of the client which sent the request. If the request was sent by a trusted proxy,
+// the X-Forwarded-For header is evaluated from right to left and the first address which does not belong to a trusted
+// proxy is returned. Addresses in the header which were added by untrusted clients are never used.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) (net.IP, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, errors.Errorf("unable to parse the remote address %s", r.RemoteAddr)
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for k := len(forwarded) - 1; k >= 0 && ContainsIP(trustedProxies, ip); k-- {
+		next := net.ParseIP(strings.TrimSpace(forwarded[k]))
+		if next == nil {
+			break
+		}
+		ip = next
+	}
+
+	return ip, nil
+}
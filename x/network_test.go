@@ -0,0 +1,66 @@
+package x
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetwork(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		expect string
+		err    bool
+	}{
+		{in: "10.0.0.0/8", expect: "10.0.0.0/8"},
+		{in: "10.0.0.1", expect: "10.0.0.1/32"},
+		{in: "::1", expect: "::1/128"},
+		{in: "10.0.0.0/33", err: true},
+		{in: "not-an-ip", err: true},
+	} {
+		n, err := ParseNetwork(tc.in)
+		if tc.err {
+			assert.Error(t, err, tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.expect, n.String())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseNetworks([]string{"192.168.0.0/16"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		d          string
+		remoteAddr string
+		forwarded  []string
+		trusted    bool
+		expect     string
+	}{
+		{d: "remote address", remoteAddr: "10.0.0.1:1234", expect: "10.0.0.1"},
+		{d: "ignores the header of untrusted clients", remoteAddr: "10.0.0.1:1234", forwarded: []string{"10.0.0.2"}, trusted: true, expect: "10.0.0.1"},
+		{d: "ignores the header without trusted proxies", remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.0.0.2"}, expect: "192.168.0.1"},
+		{d: "uses the header of trusted proxies", remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.0.0.2, 10.0.0.1", "192.168.0.2"}, trusted: true, expect: "10.0.0.1"},
+		{d: "stops at invalid addresses", remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.0.0.1, foo"}, trusted: true, expect: "192.168.0.1"},
+		{d: "uses the last trusted proxy if all addresses are trusted", remoteAddr: "192.168.0.1:1234", forwarded: []string{"192.168.0.2"}, trusted: true, expect: "192.168.0.2"},
+	} {
+		t.Run(tc.d, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tc.remoteAddr, Header: http.Header{"X-Forwarded-For": tc.forwarded}}
+			proxies := trusted
+			if !tc.trusted {
+				proxies = nil
+			}
+
+			ip, err := ClientIP(r, proxies)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, ip.String())
+		})
+	}
+
+	_, err = ClientIP(&http.Request{RemoteAddr: "foo"}, nil)
+	assert.Error(t, err)
+}